@@ -0,0 +1,169 @@
+package jonson
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AuditEntry is a single entry within the audit log
+type AuditEntry struct {
+	Action string
+	Target string
+	Detail string
+	Time   time.Time
+	// Failed is set in case the request failed and
+	// the audit policy persists failed requests
+	Failed bool
+	// PrevHash and Hash are set in case entries are chained
+	PrevHash string
+	Hash     string
+}
+
+// AuditSink persists audit entries
+type AuditSink interface {
+	Persist(entries []*AuditEntry) error
+}
+
+// AuditPolicy defines how audit entries of failed requests are handled
+type AuditPolicy int
+
+const (
+	// AuditDropOnFailure drops all entries of failed requests
+	AuditDropOnFailure AuditPolicy = iota
+	// AuditPersistOnFailure persists entries of failed requests
+	// with the failed flag set
+	AuditPersistOnFailure
+)
+
+// AuditChain links audit entries using a running hash
+// which allows us to detect tampering of persisted entries.
+// A single chain should be shared by all requests.
+type AuditChain struct {
+	mu       sync.Mutex
+	lastHash string
+}
+
+// NewAuditChain returns a new chain; lastHash is the hash
+// of the last persisted entry and may be empty for new chains
+func NewAuditChain(lastHash string) *AuditChain {
+	return &AuditChain{
+		lastHash: lastHash,
+	}
+}
+
+// hashAuditEntry returns the hash of the entry linked to prevHash
+func hashAuditEntry(prevHash string, e *AuditEntry) string {
+	h := sha256.New()
+	for _, v := range []string{prevHash, e.Action, e.Target, e.Detail, strconv.FormatInt(e.Time.UnixNano(), 10), strconv.FormatBool(e.Failed)} {
+		h.Write([]byte(strconv.Itoa(len(v)) + ":" + v))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyAuditChain verifies entries persisted in order are linked
+// properly and have not been tampered with
+func VerifyAuditChain(entries []*AuditEntry) error {
+	for i, e := range entries {
+		if i > 0 && e.PrevHash != entries[i-1].Hash {
+			return fmt.Errorf("audit chain: entry %d is not linked to its predecessor", i)
+		}
+		if hashAuditEntry(e.PrevHash, e) != e.Hash {
+			return fmt.Errorf("audit chain: entry %d has been tampered with", i)
+		}
+	}
+	return nil
+}
+
+// AuditOptions configure the audit log
+type AuditOptions struct {
+	Policy AuditPolicy
+	// Chain links entries using a running hash; optional
+	Chain *AuditChain
+}
+
+// Audit buffers audit entries of the ongoing request.
+// Entries are persisted once the context finalizes successfully.
+// Provide the audit using a provider, e.g.:
+//
+//	fac.RegisterProviderFunc(func(ctx *jonson.Context) *jonson.Audit {
+//		return jonson.NewAudit(sink, &jonson.AuditOptions{Chain: chain})
+//	})
+type Audit struct {
+	sink    AuditSink
+	options *AuditOptions
+	mu      sync.Mutex
+	entries []*AuditEntry
+}
+
+func NewAudit(sink AuditSink, options *AuditOptions) *Audit {
+	if options == nil {
+		options = &AuditOptions{}
+	}
+	return &Audit{
+		sink:    sink,
+		options: options,
+	}
+}
+
+// Record buffers a new audit entry
+func (a *Audit) Record(action string, target string, detail string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, &AuditEntry{
+		Action: action,
+		Target: target,
+		Detail: detail,
+		Time:   time.Now(),
+	})
+}
+
+func (a *Audit) Finalize(errs []error) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries := a.entries
+	a.entries = nil
+	if len(entries) == 0 {
+		return nil
+	}
+
+	failed := len(errs) > 0
+	if failed && a.options.Policy == AuditDropOnFailure {
+		return nil
+	}
+	for _, e := range entries {
+		e.Failed = failed
+	}
+
+	chain := a.options.Chain
+	if chain == nil {
+		return a.persist(entries)
+	}
+
+	// keep the chain locked until entries are persisted
+	// so entries are persisted in chain order
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	lastHash := chain.lastHash
+	for _, e := range entries {
+		e.PrevHash = lastHash
+		e.Hash = hashAuditEntry(lastHash, e)
+		lastHash = e.Hash
+	}
+	if err := a.persist(entries); err != nil {
+		return err
+	}
+	chain.lastHash = lastHash
+	return nil
+}
+
+func (a *Audit) persist(entries []*AuditEntry) error {
+	if err := a.sink.Persist(entries); err != nil {
+		return fmt.Errorf("audit: failed to persist entries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type auditTestSink struct {
+	entries []*AuditEntry
+}
+
+func (a *auditTestSink) Persist(entries []*AuditEntry) error {
+	a.entries = append(a.entries, entries...)
+	return nil
+}
+
+var typeAudit = reflect.TypeOf((**Audit)(nil)).Elem()
+
+func newAuditTestContext(sink AuditSink, options *AuditOptions) *Context {
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *Audit {
+		return NewAudit(sink, options)
+	})
+	return NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+}
+
+func TestAudit(t *testing.T) {
+	t.Run("expect entries to be persisted on success", func(t *testing.T) {
+		sink := &auditTestSink{}
+		ctx := newAuditTestContext(sink, nil)
+		ctx.Require(typeAudit).(*Audit).Record("update", "account/1", "name changed")
+		if err := ctx.Finalize(nil); err != nil {
+			t.Fatal(err)
+		}
+		if len(sink.entries) != 1 || sink.entries[0].Action != "update" || sink.entries[0].Failed {
+			t.Fatalf("expected a single successful entry, got: %+v", sink.entries)
+		}
+	})
+
+	t.Run("expect entries to be dropped on failure", func(t *testing.T) {
+		sink := &auditTestSink{}
+		ctx := newAuditTestContext(sink, nil)
+		ctx.Require(typeAudit).(*Audit).Record("update", "account/1", "")
+		ctx.Finalize(errors.New("failed"))
+		if len(sink.entries) != 0 {
+			t.Fatalf("expected entries to be dropped, got: %+v", sink.entries)
+		}
+	})
+
+	t.Run("expect entries to be flagged on failure", func(t *testing.T) {
+		sink := &auditTestSink{}
+		ctx := newAuditTestContext(sink, &AuditOptions{Policy: AuditPersistOnFailure})
+		ctx.Require(typeAudit).(*Audit).Record("update", "account/1", "")
+		ctx.Finalize(errors.New("failed"))
+		if len(sink.entries) != 1 || !sink.entries[0].Failed {
+			t.Fatalf("expected a single failed entry, got: %+v", sink.entries)
+		}
+	})
+
+	t.Run("expect entries to be chained", func(t *testing.T) {
+		sink := &auditTestSink{}
+		chain := NewAuditChain("")
+		for i := 0; i < 2; i++ {
+			ctx := newAuditTestContext(sink, &AuditOptions{Chain: chain})
+			audit := ctx.Require(typeAudit).(*Audit)
+			audit.Record("create", "account/1", "")
+			audit.Record("update", "account/1", "")
+			ctx.Finalize(nil)
+		}
+		if len(sink.entries) != 4 {
+			t.Fatalf("expected 4 entries, got: %d", len(sink.entries))
+		}
+		if sink.entries[2].PrevHash != sink.entries[1].Hash {
+			t.Fatal("expected entries of consecutive requests to be linked")
+		}
+		if err := VerifyAuditChain(sink.entries); err != nil {
+			t.Fatal(err)
+		}
+
+		sink.entries[1].Detail = "tampered"
+		if err := VerifyAuditChain(sink.entries); err == nil {
+			t.Fatal("expected tampering to be detected")
+		}
+	})
+}
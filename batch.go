@@ -0,0 +1,40 @@
+package jonson
+
+import "encoding/json"
+
+// BatchPolicy defines how batches handle failing calls
+type BatchPolicy int
+
+const (
+	// BatchContinueOnError processes all calls of a batch
+	// regardless of failing calls (default)
+	BatchContinueOnError BatchPolicy = iota
+	// BatchStopOnError stops processing a batch once a call failed;
+	// the remaining calls will be answered using ErrBatchAborted
+	BatchStopOnError
+)
+
+// SetBatchPolicy sets the policy of batch requests;
+// defaults to BatchContinueOnError
+func (m *MethodHandler) SetBatchPolicy(policy BatchPolicy) {
+	m.batchPolicy = policy
+}
+
+// abortBatch returns the responses of the remaining calls
+// which will not be processed due to the batch policy
+func abortBatch(rpcRequests []json.RawMessage) []any {
+	out := []any{}
+	for _, raw := range rpcRequests {
+		rpcRequest := &RPCRequest{}
+		if err := json.Unmarshal(raw, rpcRequest); err != nil {
+			out = append(out, NewRPCErrorResponse(nil, ErrParse))
+			continue
+		}
+		if rpcRequest.ID == nil {
+			// notifications are never answered
+			continue
+		}
+		out = append(out, NewRPCErrorResponse(rpcRequest.ID, ErrBatchAborted))
+	}
+	return out
+}
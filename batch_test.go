@@ -0,0 +1,74 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type batchTestParams struct {
+	Params
+	Fail bool `json:"fail"`
+}
+
+func TestBatchPolicy(t *testing.T) {
+	calls := 0
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterMethod(&MethodDefinition{
+		System:  "batch-test",
+		Method:  "call",
+		Version: 1,
+		HandlerFunc: func(ctx *Context, params *batchTestParams) (int, error) {
+			calls++
+			if params.Fail {
+				return 0, ErrInternal
+			}
+			return calls, nil
+		},
+	})
+
+	call := func(t *testing.T) []*RPCErrorResponse {
+		t.Helper()
+		calls = 0
+		body := []byte(`[
+			{"jsonrpc":"2.0","id":1,"method":"batch-test/call.v1","params":{}},
+			{"jsonrpc":"2.0","id":2,"method":"batch-test/call.v1","params":{"fail":true}},
+			{"jsonrpc":"2.0","id":3,"method":"batch-test/call.v1","params":{}},
+			{"jsonrpc":"2.0","method":"batch-test/call.v1","params":{}}
+		]`)
+		w := httptest.NewRecorder()
+		NewHttpRpcHandler(mh, "/rpc").Handle(w, httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(body)))
+		out := []*RPCErrorResponse{}
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	t.Run("expect all calls to be processed by default", func(t *testing.T) {
+		resp := call(t)
+		if calls != 4 {
+			t.Fatalf("expected 4 calls, got: %d", calls)
+		}
+		if len(resp) != 3 || resp[2].Error != nil {
+			t.Fatalf("expected subsequent call to succeed, got: %+v", resp)
+		}
+	})
+
+	mh.SetBatchPolicy(BatchStopOnError)
+
+	t.Run("expect remaining calls to be aborted on error", func(t *testing.T) {
+		resp := call(t)
+		if calls != 2 {
+			t.Fatalf("expected 2 calls, got: %d", calls)
+		}
+		if len(resp) != 3 || resp[0].Error != nil || resp[1].Error.Code != ErrInternal.Code {
+			t.Fatalf("expected first call to succeed and second to fail, got: %+v", resp)
+		}
+		if resp[2].Error == nil || resp[2].Error.Code != ErrBatchAborted.Code || string(resp[2].ID) != "3" {
+			t.Fatalf("expected third call to be aborted, got: %+v", resp[2])
+		}
+	})
+}
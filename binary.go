@@ -0,0 +1,90 @@
+package jonson
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// ContentTypeOctetStream is the default content type of binary results
+const ContentTypeOctetStream = "application/octet-stream"
+
+// BinaryResult may be returned by methods serving binary data,
+// e.g. generated images or file downloads.
+// Http methods respond with the raw data using the given content type
+// instead of json; other transports fail with ErrBinaryResultUnsupported.
+type BinaryResult struct {
+	ContentType string
+	Data        []byte
+	// Reader streams the data instead of Data; readers implementing
+	// io.Closer will be closed once written. Streaming happens after
+	// the context has been finalized.
+	Reader io.Reader
+	// Size is the content length of streamed data; -1 if unknown
+	Size int64
+}
+
+var typeBinaryResult = reflect.TypeOf(BinaryResult{})
+
+// NewBinaryResult returns a buffered binary result
+func NewBinaryResult(contentType string, data []byte) *BinaryResult {
+	return &BinaryResult{
+		ContentType: contentType,
+		Data:        data,
+	}
+}
+
+// NewBinaryStreamResult returns a binary result streaming the reader's data;
+// size is the content length or -1 if unknown
+func NewBinaryStreamResult(contentType string, r io.Reader, size int64) *BinaryResult {
+	return &BinaryResult{
+		ContentType: contentType,
+		Reader:      r,
+		Size:        size,
+	}
+}
+
+// close closes the reader of streamed results
+func (b *BinaryResult) close() {
+	if c, ok := b.Reader.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// checkBinaryResult fails binary results of requests
+// which do not support them
+func checkBinaryResult(res any, rpcRequest *RPCRequest) error {
+	result, ok := res.(*BinaryResult)
+	if !ok || result == nil || rpcRequest.binary {
+		return nil
+	}
+	result.close()
+	return ErrBinaryResultUnsupported
+}
+
+// writeBinaryResult writes the raw data of the binary result
+func writeBinaryResult(w http.ResponseWriter, result *BinaryResult) {
+	contentType := result.ContentType
+	if contentType == "" {
+		contentType = ContentTypeOctetStream
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	if result.Reader == nil {
+		w.Header().Set("Content-Length", strconv.Itoa(len(result.Data)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(result.Data)
+		return
+	}
+
+	defer result.close()
+	if result.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(result.Size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, result.Reader); err != nil {
+		log.Print("rpc http handler: binary result write error: ", err)
+	}
+}
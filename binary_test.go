@@ -0,0 +1,94 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type binaryTestReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *binaryTestReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestBinaryResult(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', 0x00, 0x01}
+	var reader *binaryTestReader
+
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterMethod(&MethodDefinition{
+		System:  "binary-test",
+		Method:  "image",
+		Version: 1,
+		HandlerFunc: func(ctx *Context) (*BinaryResult, error) {
+			return NewBinaryResult("image/png", png), nil
+		},
+	})
+	mh.RegisterMethod(&MethodDefinition{
+		System:  "binary-test",
+		Method:  "download",
+		Version: 1,
+		HandlerFunc: func(ctx *Context) (*BinaryResult, error) {
+			reader = &binaryTestReader{Reader: strings.NewReader("id,name\n1,Silvio\n")}
+			return NewBinaryStreamResult("text/csv", reader, -1), nil
+		},
+	})
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		if !NewHttpMethodHandler(mh).Handle(w, httptest.NewRequest(http.MethodGet, path, nil)) {
+			t.Fatal("expected request to be handled")
+		}
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got: %d %s", w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	t.Run("expect buffered data to be written as is", func(t *testing.T) {
+		w := get(t, "/binary-test/image.v1")
+		if !bytes.Equal(w.Body.Bytes(), png) {
+			t.Fatalf("expected raw data, got: %q", w.Body.Bytes())
+		}
+		if w.Header().Get("Content-Type") != "image/png" || w.Header().Get("Content-Length") != "6" {
+			t.Fatalf("unexpected headers: %v", w.Header())
+		}
+	})
+
+	t.Run("expect streamed data to be written and closed", func(t *testing.T) {
+		w := get(t, "/binary-test/download.v1")
+		if w.Body.String() != "id,name\n1,Silvio\n" {
+			t.Fatalf("unexpected body: %q", w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != "text/csv" || w.Header().Get("Content-Length") != "" {
+			t.Fatalf("unexpected headers: %v", w.Header())
+		}
+		if !reader.closed {
+			t.Fatal("expected reader to be closed")
+		}
+	})
+
+	t.Run("expect rpc calls to fail", func(t *testing.T) {
+		resp := callRPC(t, mh, "binary-test/download.v1", nil)
+		rpcErr := &Error{}
+		if err := json.Unmarshal(resp["error"], rpcErr); err != nil {
+			t.Fatal(err)
+		}
+		if rpcErr.Code != ErrBinaryResultUnsupported.Code {
+			t.Fatalf("expected binary result unsupported, got: %v", rpcErr)
+		}
+		if !reader.closed {
+			t.Fatal("expected reader to be closed")
+		}
+	})
+}
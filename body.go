@@ -0,0 +1,52 @@
+package jonson
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// bodyTracker keeps track of request bodies which could not be read
+// completely, e.g. because the client disconnected mid-upload
+type bodyTracker struct {
+	io.ReadCloser
+	expected   int64
+	read       int64
+	incomplete bool
+}
+
+// trackBody replaces the request's body with a tracked body
+func trackBody(req *http.Request) *bodyTracker {
+	b := &bodyTracker{
+		ReadCloser: req.Body,
+		expected:   req.ContentLength,
+	}
+	req.Body = b
+	return b
+}
+
+func (b *bodyTracker) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	switch {
+	case err == nil:
+	case errors.Is(err, io.EOF):
+		if b.expected > 0 && b.read < b.expected {
+			b.incomplete = true
+			return n, io.ErrUnexpectedEOF
+		}
+	default:
+		b.incomplete = true
+	}
+	return n, err
+}
+
+// requestIncomplete returns true in case the request's body
+// could not be read completely
+func requestIncomplete(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	b, ok := r.Body.(*bodyTracker)
+	return ok && b.incomplete
+}
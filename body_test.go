@@ -0,0 +1,100 @@
+package jonson
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bodyTestUpload struct {
+	finalized bool
+}
+
+func (b *bodyTestUpload) Finalize(errs []error) error {
+	b.finalized = true
+	return nil
+}
+
+type BodyTest struct{}
+
+func (b *BodyTest) EchoV1(ctx *Context, params *decoderTestParams) (*decoderTestParams, error) {
+	return params, nil
+}
+
+func (b *BodyTest) UploadV1(ctx *Context) error {
+	Require[*bodyTestUpload](ctx)
+	_, err := io.ReadAll(RequireHttpRequest(ctx).Body)
+	return err
+}
+
+// bodyTestTruncated simulates a client disconnecting mid-upload
+type bodyTestTruncated struct {
+	io.Reader
+}
+
+func (b *bodyTestTruncated) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if errors.Is(err, io.EOF) {
+		return n, errors.New("connection reset by peer")
+	}
+	return n, err
+}
+
+func TestRequestIncomplete(t *testing.T) {
+	upload := &bodyTestUpload{}
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *bodyTestUpload {
+		return upload
+	})
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+	mh.RegisterSystem(&BodyTest{})
+	handler := NewHttpMethodHandler(mh)
+
+	expectIncomplete := func(t *testing.T, w *httptest.ResponseRecorder) {
+		t.Helper()
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got: %d %s", w.Code, w.Body.String())
+		}
+		rpcErr := &Error{}
+		if err := json.Unmarshal(w.Body.Bytes(), rpcErr); err != nil {
+			t.Fatal(err)
+		}
+		if rpcErr.Code != ErrRequestIncomplete.Code {
+			t.Fatalf("expected request incomplete error, got: %s", w.Body.String())
+		}
+	}
+
+	t.Run("expect body shorter than its content length to be incomplete", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/body-test/echo.v1", strings.NewReader(`{"name":"Sil`))
+		req.ContentLength = 100
+		w := httptest.NewRecorder()
+		handler.Handle(w, req)
+		expectIncomplete(t, w)
+	})
+
+	t.Run("expect malformed body to remain a parse error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Handle(w, httptest.NewRequest(http.MethodPost, "/body-test/echo.v1", strings.NewReader(`{"name":`)))
+		rpcErr := &Error{}
+		if err := json.Unmarshal(w.Body.Bytes(), rpcErr); err != nil {
+			t.Fatal(err)
+		}
+		if rpcErr.Code != ErrParse.Code {
+			t.Fatalf("expected parse error, got: %s", w.Body.String())
+		}
+	})
+
+	t.Run("expect streaming read errors to be incomplete and the context to be finalized", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/body-test/upload.v1", &bodyTestTruncated{Reader: strings.NewReader("partial upload")})
+		w := httptest.NewRecorder()
+		handler.Handle(w, req)
+		expectIncomplete(t, w)
+		if !upload.finalized {
+			t.Fatal("expected context to be finalized")
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package jonson
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheControlHeader is the http header the cache control directives are rendered into
+const CacheControlHeader = "Cache-Control"
+
+var TypeCacheControl = reflect.TypeOf((**CacheControl)(nil)).Elem()
+
+// RequireCacheControl returns the cache control of the ongoing request
+func RequireCacheControl(ctx *Context) *CacheControl {
+	if v := ctx.Require(TypeCacheControl); v != nil {
+		return v.(*CacheControl)
+	}
+	return nil
+}
+
+// CacheControl collects the caching directives of a request;
+// the http method handler renders them into the Cache-Control header
+// of successful responses, other transports ignore them.
+// Conflicting directives resolve to the most restrictive one:
+// no-store wins over everything else, private wins over public
+// and the lowest max-age wins.
+type CacheControl struct {
+	mu      sync.Mutex
+	maxAge  time.Duration
+	hasAge  bool
+	public  bool
+	private bool
+	noStore bool
+}
+
+func NewCacheControl() *CacheControl {
+	return &CacheControl{}
+}
+
+// MaxAge sets the time the response may be cached
+func (c *CacheControl) MaxAge(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d < 0 {
+		d = 0
+	}
+	if !c.hasAge || d < c.maxAge {
+		c.maxAge = d
+		c.hasAge = true
+	}
+}
+
+// Public allows shared caches to store the response
+func (c *CacheControl) Public() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.public = true
+}
+
+// Private restricts caching of the response to the client
+func (c *CacheControl) Private() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.private = true
+}
+
+// NoStore forbids caching the response at all
+func (c *CacheControl) NoStore() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.noStore = true
+}
+
+// String returns the value of the Cache-Control header;
+// an empty string is returned in case no directive has been set
+func (c *CacheControl) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.noStore {
+		return "no-store"
+	}
+	directives := []string{}
+	switch {
+	case c.private:
+		directives = append(directives, "private")
+	case c.public:
+		directives = append(directives, "public")
+	}
+	if c.hasAge {
+		directives = append(directives, "max-age="+strconv.FormatInt(int64(c.maxAge/time.Second), 10))
+	}
+	return strings.Join(directives, ", ")
+}
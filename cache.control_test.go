@@ -0,0 +1,69 @@
+package jonson
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type CacheControlTest struct{}
+
+func (c *CacheControlTest) GetV1(ctx *Context) (string, error) {
+	cc := RequireCacheControl(ctx)
+	cc.MaxAge(time.Minute)
+	cc.Private()
+	return "cached", nil
+}
+
+func (c *CacheControlTest) FailV1(ctx *Context) error {
+	RequireCacheControl(ctx).MaxAge(time.Minute)
+	return ErrInternal
+}
+
+func TestCacheControl(t *testing.T) {
+	tests := []struct {
+		name     string
+		apply    func(cc *CacheControl)
+		expected string
+	}{
+		{"nothing", func(cc *CacheControl) {}, ""},
+		{"max-age", func(cc *CacheControl) { cc.MaxAge(30 * time.Second) }, "max-age=30"},
+		{"lowest max-age", func(cc *CacheControl) { cc.MaxAge(time.Minute); cc.MaxAge(30 * time.Second); cc.MaxAge(time.Hour) }, "max-age=30"},
+		{"private max-age", func(cc *CacheControl) { cc.MaxAge(30 * time.Second); cc.Private() }, "private, max-age=30"},
+		{"private over public", func(cc *CacheControl) { cc.Public(); cc.Private() }, "private"},
+		{"no-store over everything", func(cc *CacheControl) { cc.Public(); cc.MaxAge(time.Minute); cc.NoStore() }, "no-store"},
+	}
+	for _, tt := range tests {
+		t.Run("expect "+tt.name+" to render "+tt.expected, func(t *testing.T) {
+			cc := NewCacheControl()
+			tt.apply(cc)
+			if out := cc.String(); out != tt.expected {
+				t.Fatalf("expected %q, got: %q", tt.expected, out)
+			}
+		})
+	}
+
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterSystem(&CacheControlTest{})
+	handler := NewHttpMethodHandler(mh)
+
+	t.Run("expect http responses to carry the directives", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Handle(w, httptest.NewRequest(http.MethodGet, "/cache-control-test/get.v1", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got: %d %s", w.Code, w.Body.String())
+		}
+		if v := w.Header().Get(CacheControlHeader); v != "private, max-age=60" {
+			t.Fatalf("expected cache control header, got: %q", v)
+		}
+	})
+
+	t.Run("expect failed responses to carry no directives", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Handle(w, httptest.NewRequest(http.MethodGet, "/cache-control-test/fail.v1", nil))
+		if v := w.Header().Get(CacheControlHeader); v != "" {
+			t.Fatalf("expected no cache control header, got: %q", v)
+		}
+	})
+}
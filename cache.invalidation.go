@@ -0,0 +1,97 @@
+package jonson
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DefaultMaxInvalidations is the default number of keys
+// a cache invalidator buffers per request
+const DefaultMaxInvalidations = 1000
+
+// ErrTooManyInvalidations is returned by Invalidate in case
+// the buffer of the cache invalidator is full
+var ErrTooManyInvalidations = errors.New("cache invalidator: too many invalidations")
+
+// InvalidationSink invalidates cached entries, e.g. within a CDN or redis
+type InvalidationSink interface {
+	Invalidate(keys []string) error
+}
+
+// CacheInvalidatorOptions configure the cache invalidator
+type CacheInvalidatorOptions struct {
+	// MaxKeys bounds the number of distinct keys buffered per request;
+	// defaults to DefaultMaxInvalidations
+	MaxKeys int
+}
+
+// CacheInvalidator buffers keys of cache entries to be invalidated.
+// The keys are deduplicated and flushed to the sink once the context
+// finalizes successfully; the keys will be dropped in case the request failed
+// so caches are not invalidated for rolled back transactions.
+// Provide the invalidator using a provider, e.g.:
+//
+//	fac.RegisterProviderFunc(func(ctx *jonson.Context) *jonson.CacheInvalidator {
+//		return jonson.NewCacheInvalidator(sink, nil)
+//	})
+type CacheInvalidator struct {
+	sink    InvalidationSink
+	options *CacheInvalidatorOptions
+	mu      sync.Mutex
+	seen    map[string]struct{}
+	keys    []string
+}
+
+func NewCacheInvalidator(sink InvalidationSink, options *CacheInvalidatorOptions) *CacheInvalidator {
+	opts := CacheInvalidatorOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = DefaultMaxInvalidations
+	}
+	return &CacheInvalidator{
+		sink:    sink,
+		options: &opts,
+		seen:    map[string]struct{}{},
+	}
+}
+
+// Invalidate buffers the key; keys which have already been buffered
+// will be ignored. ErrTooManyInvalidations is returned in case the buffer is full.
+func (c *CacheInvalidator) Invalidate(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.seen[key]; ok {
+		return nil
+	}
+	if len(c.keys) >= c.options.MaxKeys {
+		return ErrTooManyInvalidations
+	}
+	c.seen[key] = struct{}{}
+	c.keys = append(c.keys, key)
+	return nil
+}
+
+// FinalizePhase makes sure we finalize after values of the default phase
+// (e.g. transactions) so failed commits prevent the invalidation
+func (c *CacheInvalidator) FinalizePhase() int {
+	return DefaultFinalizePhase + 1
+}
+
+func (c *CacheInvalidator) Finalize(errs []error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := c.keys
+	c.keys = nil
+	c.seen = map[string]struct{}{}
+	if len(keys) == 0 || len(errs) > 0 {
+		return nil
+	}
+	if err := c.sink.Invalidate(keys); err != nil {
+		return fmt.Errorf("cache invalidator: failed to invalidate keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type cacheInvalidationTestSink struct {
+	keys []string
+}
+
+func (c *cacheInvalidationTestSink) Invalidate(keys []string) error {
+	c.keys = append(c.keys, keys...)
+	return nil
+}
+
+type cacheInvalidationTestTx struct {
+	err error
+}
+
+func (c *cacheInvalidationTestTx) Finalize(errs []error) error {
+	return c.err
+}
+
+func newCacheInvalidationTestContext(sink InvalidationSink, options *CacheInvalidatorOptions, tx *cacheInvalidationTestTx) *Context {
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *CacheInvalidator {
+		return NewCacheInvalidator(sink, options)
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *cacheInvalidationTestTx {
+		return tx
+	})
+	return NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+}
+
+func TestCacheInvalidator(t *testing.T) {
+	t.Run("expect keys to be deduplicated and flushed on success", func(t *testing.T) {
+		sink := &cacheInvalidationTestSink{}
+		ctx := newCacheInvalidationTestContext(sink, nil, nil)
+		invalidator := Require[*CacheInvalidator](ctx)
+		for _, key := range []string{"account/1", "account/2", "account/1"} {
+			if err := invalidator.Invalidate(key); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := ctx.Finalize(nil); err != nil {
+			t.Fatal(err)
+		}
+		if expected := []string{"account/1", "account/2"}; !reflect.DeepEqual(sink.keys, expected) {
+			t.Fatalf("expected keys %v, got: %v", expected, sink.keys)
+		}
+	})
+
+	t.Run("expect keys to be skipped on failure", func(t *testing.T) {
+		sink := &cacheInvalidationTestSink{}
+		ctx := newCacheInvalidationTestContext(sink, nil, nil)
+		Require[*CacheInvalidator](ctx).Invalidate("account/1")
+		ctx.Finalize(errors.New("failed"))
+		if len(sink.keys) != 0 {
+			t.Fatalf("expected no keys to be flushed, got: %v", sink.keys)
+		}
+	})
+
+	t.Run("expect keys to be skipped in case the transaction fails to commit", func(t *testing.T) {
+		sink := &cacheInvalidationTestSink{}
+		ctx := newCacheInvalidationTestContext(sink, nil, &cacheInvalidationTestTx{err: errors.New("commit failed")})
+		// provisioned before the transaction, finalized after it nonetheless
+		Require[*CacheInvalidator](ctx).Invalidate("account/1")
+		Require[*cacheInvalidationTestTx](ctx)
+		ctx.Finalize(nil)
+		if len(sink.keys) != 0 {
+			t.Fatalf("expected no keys to be flushed, got: %v", sink.keys)
+		}
+	})
+
+	t.Run("expect buffer to be bounded", func(t *testing.T) {
+		ctx := newCacheInvalidationTestContext(&cacheInvalidationTestSink{}, &CacheInvalidatorOptions{MaxKeys: 1}, nil)
+		invalidator := Require[*CacheInvalidator](ctx)
+		if err := invalidator.Invalidate("account/1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := invalidator.Invalidate("account/1"); err != nil {
+			t.Fatalf("expected duplicates not to count, got: %s", err)
+		}
+		if err := invalidator.Invalidate("account/2"); !errors.Is(err, ErrTooManyInvalidations) {
+			t.Fatalf("expected too many invalidations, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package jonson
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time;
+// replace the clock using SetClock within tests
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock returns the system's time
+type SystemClock struct{}
+
+var _ Clock = SystemClock{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SetClock sets the clock used by contexts created by the method handler;
+// defaults to SystemClock
+func (m *MethodHandler) SetClock(clock Clock) {
+	m.clock = clock
+}
+
+// FakeClock is a manually advanced clock for tests;
+// it is safe for concurrent use
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+var _ Clock = (*FakeClock)(nil)
+
+// NewFakeClock returns a fake clock starting at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Advance moves the clock forward by d
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// Set moves the clock to now
+func (f *FakeClock) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
@@ -0,0 +1,84 @@
+package jonson
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFakeClockDeadlines(t *testing.T) {
+	fac := newContextTestFactory()
+	deadline := time.Now().Add(time.Hour)
+	clock := NewFakeClock(deadline.Add(-time.Second))
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+	mh.SetClock(clock)
+
+	parent, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	ctx := NewContext(parent, fac, mh)
+
+	t.Run("expect remaining time to follow the clock", func(t *testing.T) {
+		clock.Set(deadline.Add(-time.Second))
+		if remaining, ok := ctx.RemainingTime(); !ok || remaining != time.Second {
+			t.Fatalf("expected 1s, got: %v", remaining)
+		}
+		clock.Advance(400 * time.Millisecond)
+		if remaining, _ := ctx.RemainingTime(); remaining != 600*time.Millisecond {
+			t.Fatalf("expected 600ms, got: %v", remaining)
+		}
+		clock.Advance(time.Second)
+		if remaining, _ := ctx.RemainingTime(); remaining != 0 {
+			t.Fatalf("expected 0 once the deadline passed, got: %v", remaining)
+		}
+	})
+
+	t.Run("expect budget to split the remaining time exactly", func(t *testing.T) {
+		clock.Set(deadline.Add(-900 * time.Millisecond))
+		budget := ctx.Budget(3)
+		for _, v := range budget {
+			if v != 300*time.Millisecond {
+				t.Fatalf("expected 300ms slices, got: %v", budget)
+			}
+		}
+		clock.Advance(600 * time.Millisecond)
+		if budget := ctx.WeightedBudget(1, 2); budget[0] != 100*time.Millisecond || budget[1] != 200*time.Millisecond {
+			t.Fatalf("expected 100ms and 200ms slices, got: %v", budget)
+		}
+	})
+
+	t.Run("expect timeout warning to follow the clock", func(t *testing.T) {
+		clock := NewFakeClock(time.Now())
+		mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+		mh.SetClock(clock)
+		mh.RegisterMethod(&MethodDefinition{
+			System:  "clock-test",
+			Method:  "advance",
+			Version: 1,
+			HandlerFunc: func(ctx *Context, params *struct {
+				Params
+				Millis int `json:"millis"`
+			}) error {
+				clock.Advance(time.Duration(params.Millis) * time.Millisecond)
+				return nil
+			},
+		}, Timeout(time.Minute))
+
+		warnings := func(resp map[string]json.RawMessage) []*Warning {
+			out := []*Warning{}
+			if resp["warnings"] != nil {
+				if err := json.Unmarshal(resp["warnings"], &out); err != nil {
+					t.Fatal(err)
+				}
+			}
+			return out
+		}
+
+		if w := warnings(callRPC(t, mh, "clock-test/advance.v1", map[string]any{"millis": 47000})); len(w) != 0 {
+			t.Fatalf("expected no warnings, got: %v", w)
+		}
+		if w := warnings(callRPC(t, mh, "clock-test/advance.v1", map[string]any{"millis": 50000})); len(w) != 1 || w[0].Code != WarningTimeoutBudget {
+			t.Fatalf("expected timeout budget warning, got: %v", w)
+		}
+	})
+}
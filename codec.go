@@ -0,0 +1,170 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ResponseCodec serializes whole response envelopes of http transports;
+// the codec is selected using the client's Accept header.
+// Envelopes only use exported fields tagged with json tags
+// so any codec capable of marshaling go structs may be used.
+type ResponseCodec interface {
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+}
+
+// JSONCodec encodes responses as json; it is the default codec
+type JSONCodec struct{}
+
+var _ ResponseCodec = (*JSONCodec)(nil)
+
+func NewJSONCodec() *JSONCodec {
+	return &JSONCodec{}
+}
+
+func (c *JSONCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (c *JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// MsgpackCodec encodes responses as msgpack.
+// Field names are taken from the struct's json tags
+// so the same result structs can be used for json and msgpack.
+type MsgpackCodec struct{}
+
+var _ ResponseCodec = (*MsgpackCodec)(nil)
+
+func NewMsgpackCodec() *MsgpackCodec {
+	return &MsgpackCodec{}
+}
+
+func (c *MsgpackCodec) ContentType() string {
+	return ContentTypeMsgpack
+}
+
+func (c *MsgpackCodec) Marshal(v any) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := msgpack.NewEncoder(buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RegisterResponseCodec registers a codec for its content type.
+// Existing codecs will be replaced.
+func (m *MethodHandler) RegisterResponseCodec(codec ResponseCodec) {
+	m.codecs[codec.ContentType()] = codec
+}
+
+// responseCodec returns the registered codec preferred by the client
+// according to the Accept header's q-values; media types with q=0 are refused.
+// Defaults to json
+func (m *MethodHandler) responseCodec(r *http.Request) ResponseCodec {
+	type candidate struct {
+		mediaType string
+		q         float64
+	}
+	candidates := []candidate{}
+	for _, v := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(v))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		candidates = append(candidates, candidate{mediaType: mediaType, q: q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	for _, c := range candidates {
+		if codec, ok := m.codecs[c.mediaType]; ok && c.q > 0 {
+			return codec
+		}
+	}
+	return m.codecs[ContentTypeJSON]
+}
+
+// setResponseContentType sets the content type of responses
+// not encoded using the default json codec
+func setResponseContentType(w http.ResponseWriter, codec ResponseCodec) {
+	if _, ok := codec.(*JSONCodec); !ok {
+		w.Header().Set("Content-Type", codec.ContentType())
+	}
+}
+
+// codecResponseHeader mirrors RPCResponseHeader; the raw json id
+// is decoded so codecs other than json encode it as a plain value
+type codecResponseHeader struct {
+	Version  string     `json:"jsonrpc"`
+	ID       any        `json:"id"`
+	Warnings []*Warning `json:"warnings,omitempty"`
+}
+
+type codecResultResponse struct {
+	codecResponseHeader
+	Result any `json:"result"`
+}
+
+type codecErrorResponse struct {
+	codecResponseHeader
+	Error *Error `json:"error"`
+}
+
+func newCodecResponseHeader(h RPCResponseHeader) codecResponseHeader {
+	out := codecResponseHeader{
+		Version:  h.Version,
+		Warnings: h.Warnings,
+	}
+	if len(h.ID) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(h.ID))
+		dec.UseNumber()
+		var id any
+		if err := dec.Decode(&id); err == nil {
+			out.ID = id
+		}
+		if n, ok := id.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				out.ID = i
+			} else if f, err := n.Float64(); err == nil {
+				out.ID = f
+			}
+		}
+	}
+	return out
+}
+
+// codecValue converts response envelopes into codec agnostic values;
+// everything else is returned as is
+func codecValue(resp any) any {
+	switch v := resp.(type) {
+	case *RPCResultResponse:
+		return &codecResultResponse{
+			codecResponseHeader: newCodecResponseHeader(v.RPCResponseHeader),
+			Result:              v.Result,
+		}
+	case *RPCErrorResponse:
+		return &codecErrorResponse{
+			codecResponseHeader: newCodecResponseHeader(v.RPCResponseHeader),
+			Error:               v.Error,
+		}
+	}
+	return resp
+}
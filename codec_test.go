@@ -0,0 +1,121 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type codecTestResult struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags"`
+}
+
+// codecTestResponse is the response envelope as seen by clients
+type codecTestResponse struct {
+	Version string           `json:"jsonrpc"`
+	ID      int64            `json:"id"`
+	Result  *codecTestResult `json:"result"`
+	Error   *Error           `json:"error"`
+}
+
+func TestResponseCodec(t *testing.T) {
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterMethod(&MethodDefinition{
+		System:  "codec-test",
+		Method:  "get",
+		Version: 1,
+		HandlerFunc: func(ctx *Context) (*codecTestResult, error) {
+			return &codecTestResult{Name: "Silvio", Count: 3, Tags: []string{"a", "b"}}, nil
+		},
+	})
+	mh.RegisterMethod(&MethodDefinition{
+		System:  "codec-test",
+		Method:  "fail",
+		Version: 1,
+		HandlerFunc: func(ctx *Context) error {
+			return ErrUnauthorized
+		},
+	})
+
+	call := func(t *testing.T, accept string, method string) *httptest.ResponseRecorder {
+		t.Helper()
+		body := `{"jsonrpc":"2.0","id":7,"method":"` + method + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader([]byte(body)))
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		NewHttpRpcHandler(mh, "/rpc").Handle(w, req)
+		return w
+	}
+
+	decoders := map[string]func(t *testing.T, b []byte) *codecTestResponse{
+		ContentTypeJSON: func(t *testing.T, b []byte) *codecTestResponse {
+			out := &codecTestResponse{}
+			if err := json.Unmarshal(b, out); err != nil {
+				t.Fatal(err)
+			}
+			return out
+		},
+		ContentTypeMsgpack: func(t *testing.T, b []byte) *codecTestResponse {
+			out := &codecTestResponse{}
+			dec := msgpack.NewDecoder(bytes.NewReader(b))
+			dec.SetCustomStructTag("json")
+			if err := dec.Decode(out); err != nil {
+				t.Fatal(err)
+			}
+			return out
+		},
+	}
+
+	for contentType, decode := range decoders {
+		t.Run("expect "+contentType+" result envelope to round trip", func(t *testing.T) {
+			w := call(t, contentType, "codec-test/get.v1")
+			out := decode(t, w.Body.Bytes())
+			if out.Version != "2.0" || out.ID != 7 || out.Result == nil || out.Result.Name != "Silvio" || out.Result.Count != 3 || len(out.Result.Tags) != 2 {
+				t.Fatalf("unexpected response: %+v", out)
+			}
+		})
+
+		t.Run("expect "+contentType+" error envelope to round trip", func(t *testing.T) {
+			w := call(t, contentType, "codec-test/fail.v1")
+			out := decode(t, w.Body.Bytes())
+			if out.ID != 7 || out.Error == nil || out.Error.Code != ErrUnauthorized.Code || out.Error.Message != ErrUnauthorized.Message {
+				t.Fatalf("unexpected response: %+v", out)
+			}
+		})
+	}
+
+	t.Run("expect content type to be set for non-json codecs", func(t *testing.T) {
+		if w := call(t, "application/xml, application/msgpack", "codec-test/get.v1"); w.Header().Get("Content-Type") != ContentTypeMsgpack {
+			t.Fatalf("expected msgpack content type, got: %s", w.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("expect codecs to be selected by their q-values", func(t *testing.T) {
+		tests := map[string]string{
+			"application/json;q=0.5, application/msgpack": ContentTypeMsgpack,
+			"application/msgpack;q=0.5, application/json": "",
+			"application/msgpack;q=0.0, application/json": "",
+			"application/msgpack;q=0.000":                 "",
+		}
+		for accept, expected := range tests {
+			if w := call(t, accept, "codec-test/get.v1"); w.Header().Get("Content-Type") != expected {
+				t.Fatalf("expected content type %q for %q, got: %q", expected, accept, w.Header().Get("Content-Type"))
+			}
+		}
+	})
+
+	t.Run("expect json for unknown accept headers", func(t *testing.T) {
+		w := call(t, "text/html, */*;q=0.8", "codec-test/get.v1")
+		if !json.Valid(w.Body.Bytes()) {
+			t.Fatalf("expected json, got: %q", w.Body.String())
+		}
+	})
+}
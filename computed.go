@@ -0,0 +1,106 @@
+package jonson
+
+import (
+	"reflect"
+	"sync"
+)
+
+// computedValues contains the once blocks run by Once and the origin
+// storing the values created by RequireOrStore;
+// it is shared between a context and all of its forks
+type computedValues struct {
+	// origin is the context which has not been forked
+	origin *Context
+	mu     sync.Mutex
+	onces  map[string]*sync.Once
+}
+
+func newComputedValues(origin *Context) *computedValues {
+	return &computedValues{
+		origin: origin,
+		onces:  map[string]*sync.Once{},
+	}
+}
+
+// once returns the once of the given key
+func (s *computedValues) once(key string) *sync.Once {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.onces[key]
+	if !ok {
+		o = &sync.Once{}
+		s.onces[key] = o
+	}
+	return o
+}
+
+// RequireOrStore returns the value of type T stored within the context;
+// in case no value exists, the value will be created using the factory.
+// The value is created once and shared by the context and all of its forks,
+// even when called concurrently; subsequent calls of Require return the value as well.
+// Values implementing Finalizeable will be finalized by the context which has not been forked.
+func RequireOrStore[T any](ctx *Context, factory func() T) T {
+	v := ctx.requireOrStore(TypeOf[T](), func() any {
+		return factory()
+	})
+	if v != nil {
+		return v.(T)
+	}
+	var zero T
+	return zero
+}
+
+// requireOrStore stores the value within the context's placeholder;
+// forks borrow the value stored within their origin
+func (c *Context) requireOrStore(rt reflect.Type, factory func() any) any {
+	if err := c.checkFinalized("require " + rt.String()); err != nil {
+		panic(err)
+	}
+	v, val, err := c.reserve(rt)
+	if err != nil {
+		panic(err)
+	}
+	if v == nil {
+		return val
+	}
+
+	origin := c.computed.origin
+	borrowed := origin.contextState != c.contextState
+	c.mu.Lock()
+	// the factory's value is owned by the origin, regardless of its type
+	v.shared, v.singleton, v.borrowed = false, false, borrowed
+	c.mu.Unlock()
+
+	val = func() any {
+		defer func() {
+			if r := recover(); r != nil {
+				c.abandon(v)
+				panic(r)
+			}
+		}()
+		if borrowed {
+			return origin.requireOrStore(rt, factory)
+		}
+		return factory()
+	}()
+	c.complete(v, val)
+	return val
+}
+
+// Once runs fn the first time it is called using the given key;
+// subsequent calls using the same key are no-ops, e.g. to lazily
+// start a per-request background flusher from multiple code paths:
+//
+//	ctx.Once("flusher", func() {
+//		go flush(ctx)
+//	})
+//
+// The key is shared by the context and all of its forks, even when called
+// concurrently. Concurrent callers wait until fn returned;
+// in case fn panics, Once considers it to have returned.
+func (c *Context) Once(key string, fn func()) {
+	if err := c.checkFinalized("once " + key); err != nil {
+		panic(err)
+	}
+	c.computed.once(key).Do(fn)
+}
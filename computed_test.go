@@ -0,0 +1,156 @@
+package jonson
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type computedTestPermissions struct {
+	finalized bool
+}
+
+func (c *computedTestPermissions) Finalize(errs []error) error {
+	c.finalized = true
+	return nil
+}
+
+func TestRequireOrStore(t *testing.T) {
+	var provided atomic.Int32
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *computedTestPermissions {
+		provided.Add(1)
+		return &computedTestPermissions{}
+	})
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	t.Run("expect concurrent callers to share a single value", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		forks := make([]*Context, 10)
+		for i := range forks {
+			forks[i] = ctx.Fork()
+		}
+
+		var calls atomic.Int32
+		factory := func() *computedTestPermissions {
+			calls.Add(1)
+			return &computedTestPermissions{}
+		}
+
+		// the context's callers mix Require and RequireOrStore
+		provided.Store(0)
+		results := make([]*computedTestPermissions, len(forks)*2)
+		wg := sync.WaitGroup{}
+		for i, fork := range forks {
+			wg.Add(2)
+			go func(i int, fork *Context) {
+				defer wg.Done()
+				results[i] = RequireOrStore(fork, factory)
+			}(i, fork)
+			go func(i int) {
+				defer wg.Done()
+				if i%2 == 0 {
+					results[i] = Require[*computedTestPermissions](ctx)
+					return
+				}
+				results[i] = RequireOrStore(ctx, factory)
+			}(len(forks) + i)
+		}
+		wg.Wait()
+
+		if calls.Load()+provided.Load() != 1 {
+			t.Fatalf("expected a single invocation, got %d factory and %d provider calls", calls.Load(), provided.Load())
+		}
+		for _, v := range results {
+			if v != results[0] {
+				t.Fatal("expected all callers to receive the same value")
+			}
+		}
+
+		for _, fork := range forks {
+			fork.Finalize(nil)
+		}
+		if results[0].finalized {
+			t.Fatal("expected forks not to finalize the value")
+		}
+		ctx.Finalize(nil)
+		if !results[0].finalized {
+			t.Fatal("expected value to be finalized by the origin context")
+		}
+	})
+
+	t.Run("expect require to return the value created by the factory", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		fork := ctx.Fork()
+		provided.Store(0)
+		v := RequireOrStore(fork, func() *computedTestPermissions {
+			return &computedTestPermissions{}
+		})
+		for _, c := range []*Context{ctx, fork} {
+			if Require[*computedTestPermissions](c) != v {
+				t.Fatal("expected require to return the stored value")
+			}
+		}
+		if provided.Load() != 0 {
+			t.Fatalf("expected provider not to be invoked, got: %d", provided.Load())
+		}
+		fork.Finalize(nil)
+		ctx.Finalize(nil)
+	})
+
+	t.Run("expect stored values to be returned", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		stored := &computedTestPermissions{}
+		ctx.StoreValue(TypeOf[*computedTestPermissions](), stored)
+		v := RequireOrStore(ctx, func() *computedTestPermissions {
+			t.Fatal("expected factory not to be invoked")
+			return nil
+		})
+		if v != stored {
+			t.Fatal("expected stored value")
+		}
+	})
+}
+
+func TestContextOnce(t *testing.T) {
+	fac := NewFactory()
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	t.Run("expect a single execution per key", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		var calls atomic.Int32
+		wg := sync.WaitGroup{}
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				c := ctx
+				if i%2 == 0 {
+					c = ctx.Fork()
+				}
+				c.Once("flusher", func() {
+					calls.Add(1)
+				})
+			}(i)
+		}
+		wg.Wait()
+		if calls.Load() != 1 {
+			t.Fatalf("expected a single execution, got: %d", calls.Load())
+		}
+	})
+
+	t.Run("expect keys and contexts to be independent", func(t *testing.T) {
+		calls := 0
+		a := NewContext(context.Background(), fac, mh)
+		b := NewContext(context.Background(), fac, mh)
+		for _, ctx := range []*Context{a, b} {
+			for _, key := range []string{"a", "b", "a"} {
+				ctx.Once(key, func() { calls++ })
+			}
+		}
+		if calls != 4 {
+			t.Fatalf("expected 4 executions, got: %d", calls)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package jonson
+
+import "sync"
+
+// contextAttrs stores the attributes of a context;
+// the attributes are shared between a context and all of its forks
+type contextAttrs struct {
+	owner  *contextState
+	mu     sync.Mutex
+	values map[string]any
+}
+
+func newContextAttrs(owner *contextState) *contextAttrs {
+	return &contextAttrs{
+		owner:  owner,
+		values: map[string]any{},
+	}
+}
+
+func (a *contextAttrs) clear() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.values = map[string]any{}
+}
+
+// SetAttr stores an attribute, e.g. to pass data computed by a router
+// or dispatcher to the ones called later on or to the method itself.
+// Attributes are shared by the context and all of its forks
+// and will be cleared once the context which has not been forked finalizes.
+//
+// Attributes are meant for ephemeral cross-cutting data: unlike values
+// provided using Require, they are neither type safe nor provisioned
+// on demand nor finalized, and a missing attribute is only noticed at runtime.
+// Prefer providers for dependencies and whatever needs cleaning up.
+func (c *Context) SetAttr(key string, val any) {
+	if err := c.checkFinalized("set attribute " + key); err != nil {
+		panic(err)
+	}
+	c.attrs.mu.Lock()
+	defer c.attrs.mu.Unlock()
+	c.attrs.values[key] = val
+}
+
+// GetAttr returns an attribute; false is returned
+// in case the attribute has not been set
+func (c *Context) GetAttr(key string) (any, bool) {
+	c.attrs.mu.Lock()
+	defer c.attrs.mu.Unlock()
+	v, ok := c.attrs.values[key]
+	return v, ok
+}
+
+// Attr is the generic version of ctx.GetAttr(); false is returned
+// in case the attribute has not been set or is not of type T
+func Attr[T any](ctx *Context, key string) (T, bool) {
+	v, _ := ctx.GetAttr(key)
+	out, ok := v.(T)
+	return out, ok
+}
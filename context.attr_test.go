@@ -0,0 +1,87 @@
+package jonson
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// attrTestMiddleware runs fn before dispatching the call to next
+type attrTestMiddleware struct {
+	next Dispatcher
+	fn   func(ctx *Context) error
+}
+
+func (m *attrTestMiddleware) Dispatch(ctx *Context, rpcRequest *RPCRequest, bindata []byte) (any, error) {
+	if err := m.fn(ctx); err != nil {
+		return nil, err
+	}
+	return m.next.Dispatch(ctx, rpcRequest, bindata)
+}
+
+func TestContextAttr(t *testing.T) {
+	plan := "pro"
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterMethod(&MethodDefinition{
+		System:  "attr-test",
+		Method:  "me",
+		Version: 1,
+		HandlerFunc: func(ctx *Context) (string, error) {
+			user, _ := Attr[string](ctx, "user")
+			return user, nil
+		},
+	})
+
+	rateLimit := &attrTestMiddleware{next: mh, fn: func(ctx *Context) error {
+		if plan, ok := Attr[string](ctx, "plan"); !ok || plan == "free" {
+			return ErrUnauthorized
+		}
+		return nil
+	}}
+	auth := &attrTestMiddleware{next: rateLimit, fn: func(ctx *Context) error {
+		ctx.SetAttr("user", "silvio")
+		ctx.SetAttr("plan", plan)
+		return nil
+	}}
+	mh.SetRouter(RouterFunc(func(ctx *Context, method string) (Dispatcher, error) {
+		return auth, nil
+	}))
+
+	t.Run("expect attributes to be passed along", func(t *testing.T) {
+		resp := callRPC(t, mh, "attr-test/me.v1", nil)
+		if string(resp["result"]) != `"silvio"` {
+			t.Fatalf("expected user set by middleware, got: %s %s", resp["result"], resp["error"])
+		}
+	})
+
+	t.Run("expect later middleware to read attributes", func(t *testing.T) {
+		plan = "free"
+		defer func() { plan = "pro" }()
+		resp := callRPC(t, mh, "attr-test/me.v1", nil)
+		rpcErr := &Error{}
+		if err := json.Unmarshal(resp["error"], rpcErr); err != nil || rpcErr.Code != ErrUnauthorized.Code {
+			t.Fatalf("expected rate limit to reject free plan, got: %s", resp["error"])
+		}
+	})
+
+	t.Run("expect attributes to be shared with forks and cleared on finalize", func(t *testing.T) {
+		fac := NewFactory()
+		ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+		fork := ctx.Fork()
+		fork.SetAttr("user", "silvio")
+		if v, ok := ctx.GetAttr("user"); !ok || v != "silvio" {
+			t.Fatalf("expected attribute to be shared, got: %v", v)
+		}
+		if _, ok := Attr[int](ctx, "user"); ok {
+			t.Fatal("expected attribute of another type to be missing")
+		}
+		fork.Finalize(nil)
+		if _, ok := ctx.GetAttr("user"); !ok {
+			t.Fatal("expected fork not to clear attributes")
+		}
+		ctx.Finalize(nil)
+		if _, ok := ctx.GetAttr("user"); ok {
+			t.Fatal("expected attributes to be cleared")
+		}
+	})
+}
@@ -4,49 +4,249 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"runtime/debug"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var TypeContext = reflect.TypeOf((**Context)(nil)).Elem()
 
 type Context struct {
-	parent        context.Context
-	provider      Provider
-	methodHandler *MethodHandler
-	values        []*valueItem
-	finalized     bool
+	*contextState
+	parent context.Context
+	// provisioning is the placeholder provisioned by the provider using the context;
+	// caller is the context of the provider which required the value.
+	// The chain allows us to tell recursion loops from concurrent provisioning.
+	provisioning *valueItem
+	caller       *Context
+}
+
+// contextState is shared by a context and the views handed to its providers
+type contextState struct {
+	provider       Provider
+	methodHandler  *MethodHandler
+	mu             sync.Mutex // guards values, never held while provisioning
+	values         []*valueItem
+	finalized      atomic.Bool
+	provisioned    int
+	provisionLimit int
+	pendingWrites  PendingWritesPolicy
+	// shared contains values shared within a connection
+	shared *sharedValues
+	// singletons contains values shared by all requests;
+	// singletonRefs are released once the context finalizes
+	singletons      *singletonValues
+	singletonRefs   []*singletonInstance
+	onFinalizeError []func(rt reflect.Type, err error) error
+	afterFinalize   []func(err error)
+	clock           Clock
+	started         time.Time
+	taps            []func(rt reflect.Type, val any)
+	// finalizeRecords is nil unless recording has been enabled
+	finalizeRecords []FinalizeRecord
+	recordFinalize  bool
+	// computed contains the origin of values created by RequireOrStore
+	computed *computedValues
+	// attrs contains the attributes set using SetAttr
+	attrs *contextAttrs
+	// span is the trace span of the method currently being called
+	span *Span
+	// provisionFailure is the last provision failure reported to the observer
+	provisionFailure any
+}
+
+// FinalizeRecord describes a single value finalized by the context
+type FinalizeRecord struct {
+	Type  reflect.Type
+	Phase int
+	// Err is the error returned by the value's Finalize
+	Err error
+}
+
+// DefaultProvisionLimit defines the default number of values
+// a single context may provision
+const DefaultProvisionLimit = 10000
+
+// ErrProvisionLimitExceeded is returned whenever a context
+// provisioned more values than allowed
+var ErrProvisionLimitExceeded = errors.New("provision limit exceeded")
+
+// ProvisionLimitError contains details about the exceeded provision limit
+type ProvisionLimitError struct {
+	Count int
+	// Types contains the types which were provisioned last
+	Types []reflect.Type
+}
+
+func (e *ProvisionLimitError) Error() string {
+	types := make([]string, len(e.Types))
+	for i, v := range e.Types {
+		types[i] = v.String()
+	}
+	return fmt.Sprintf("%s: provisioned %d values, last types: %s", ErrProvisionLimitExceeded, e.Count, strings.Join(types, ", "))
+}
+
+func (e *ProvisionLimitError) Unwrap() error {
+	return ErrProvisionLimitExceeded
+}
+
+// ErrContextFinalized is returned (or panicked with) whenever
+// a finalized context is being used, e.g. by a goroutine outliving the request
+var ErrContextFinalized = errors.New("context is already finalized")
+
+// ContextFinalizedError names the operation attempted on a finalized context
+type ContextFinalizedError struct {
+	Op string
+}
+
+func (e *ContextFinalizedError) Error() string {
+	return fmt.Sprintf("%s: cannot %s", ErrContextFinalized, e.Op)
+}
+
+func (e *ContextFinalizedError) Unwrap() error {
+	return ErrContextFinalized
+}
+
+// checkFinalized returns an error in case the context is finalized
+func (c *Context) checkFinalized(op string) error {
+	if c.finalized.Load() {
+		return &ContextFinalizedError{Op: op}
+	}
+	return nil
 }
 
 type Finalizeable interface {
 	Finalize([]error) error
 }
 
+// DefaultFinalizePhase is the phase of values not implementing FinalizePhaser
+const DefaultFinalizePhase = 0
+
+// FinalizePhaser may be implemented by finalizeable values
+// which need to be finalized before or after other values regardless
+// of their provisioning order. Lower phases are finalized first;
+// values of the same phase are finalized from end to front.
+// Values without a phase use DefaultFinalizePhase, so negative phases
+// run before and positive phases run after those values.
+type FinalizePhaser interface {
+	FinalizePhase() int
+}
+
+func finalizePhase(val any) int {
+	if p, ok := val.(FinalizePhaser); ok {
+		return p.FinalizePhase()
+	}
+	return DefaultFinalizePhase
+}
+
 type valueItem struct {
 	rt    reflect.Type
 	val   any
 	valid bool
+	// shared values are owned by the connection
+	// and will not be finalized by the context
+	shared bool
+	// singleton values are owned by the method handler
+	// and will not be finalized by the context
+	singleton bool
+	// borrowed values have been merged from another context
+	// and will be finalized by their source
+	borrowed bool
+	// keyed values are stored per type and key
+	keyed bool
+	key   string
+	// invalidated is set in case the value has been invalidated
+	// while being provisioned; the value will be removed once provisioned
+	invalidated bool
+	// done is closed once the placeholder has been provisioned or abandoned
+	done chan struct{}
 }
 
 func NewContext(parent context.Context, provider Provider, methodHandler *MethodHandler) *Context {
-	ctx := &Context{
-		parent:        parent,
-		provider:      provider,
-		methodHandler: methodHandler,
+	ctx := &Context{parent: parent, contextState: &contextState{
+		provider:       provider,
+		methodHandler:  methodHandler,
+		provisionLimit: DefaultProvisionLimit,
+		clock:          SystemClock{},
+	}}
+	if methodHandler != nil && methodHandler.provisionLimit > 0 {
+		ctx.provisionLimit = methodHandler.provisionLimit
 	}
+	if methodHandler != nil && methodHandler.clock != nil {
+		ctx.clock = methodHandler.clock
+	}
+	if methodHandler != nil {
+		ctx.singletons = methodHandler.singletons
+		ctx.pendingWrites = methodHandler.pendingWrites
+	}
+	ctx.started = ctx.clock.Now()
+	ctx.computed = newComputedValues(ctx)
+	ctx.attrs = newContextAttrs(ctx.contextState)
 	ctx.StoreValue(TypeContext, ctx)
 	return ctx
 }
 
 func (c *Context) Fork() *Context {
-	return NewContext(c, c.provider, c.methodHandler)
+	return c.forkWithParent(c)
+}
+
+// forkWithParent forks the context using a different parent,
+// e.g. a parent with a shorter deadline
+func (c *Context) forkWithParent(parent context.Context) *Context {
+	if err := c.checkFinalized("fork"); err != nil {
+		panic(err)
+	}
+	ctx := NewContext(parent, c.provider, c.methodHandler)
+	ctx.shared = c.shared
+	ctx.singletons = c.singletons
+	ctx.computed = c.computed
+	ctx.attrs = c.attrs
+	ctx.span = c.span
+	return ctx
+}
+
+// Detach returns a context for background work outliving the request,
+// e.g. fire-and-forget jobs enqueued by a method:
+//
+//	job, cancel := ctx.Detach(time.Minute)
+//	go func() {
+//		defer cancel()
+//		err := doWork(job)
+//		job.Finalize(err)
+//	}()
+//
+// The detached context uses its own deadline and does not inherit the
+// request's values; values will be provisioned again on demand.
+// It is owned by the caller: the caller must call Finalize once the work is done
+// and cancel to release the deadline's resources. The request's Finalize does not
+// affect the detached context.
+// Shareable values of the connection and singletons remain owned by their connection
+// respectively the method handler; using them after the connection closes is
+// the caller's responsibility.
+func (c *Context) Detach(timeout time.Duration) (*Context, context.CancelFunc) {
+	if err := c.checkFinalized("detach"); err != nil {
+		panic(err)
+	}
+	parent, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx := NewContext(parent, c.provider, c.methodHandler)
+	ctx.shared = c.shared
+	ctx.singletons = c.singletons
+	return ctx, cancel
 }
 
 func (c *Context) StoreValue(rt reflect.Type, val any) {
+	if err := c.checkFinalized("store " + rt.String()); err != nil {
+		panic(err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	for i := range c.values {
-		if c.values[i].rt == rt {
+		if c.values[i].rt == rt && !c.values[i].keyed {
 			panic(errors.New("value of type " + rt.String() + " is already stored"))
 		}
 	}
@@ -58,28 +258,210 @@ func (c *Context) StoreValue(rt reflect.Type, val any) {
 	})
 }
 
+// ErrValueExists is returned by Merge in case a value
+// of the same type already exists within the context
+var ErrValueExists = errors.New("value already exists")
+
+// Merge copies the valid values of the given types from other into the context.
+// Merged values are borrowed: they will be finalized by other, not by c, so other
+// must outlive the usage of the merged values within c.
+// An error is returned in case a type does not exist within other or
+// already exists within c; no values will be merged in that case.
+func (c *Context) Merge(other *Context, types ...reflect.Type) error {
+	if err := c.checkFinalized("merge"); err != nil {
+		return err
+	}
+	if err := other.checkFinalized("merge"); err != nil {
+		return err
+	}
+
+	items := make([]*valueItem, 0, len(types))
+	for _, rt := range types {
+		val, ok := other.lookup(rt)
+		if !ok {
+			return fmt.Errorf("merge %s: value does not exist within source context", rt)
+		}
+		items = append(items, &valueItem{
+			rt:       rt,
+			val:      val,
+			valid:    true,
+			borrowed: true,
+		})
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, item := range items {
+		for _, values := range [][]*valueItem{c.values, items[:i]} {
+			for _, v := range values {
+				if v.rt == item.rt && !v.keyed {
+					return fmt.Errorf("merge %s: %w", item.rt, ErrValueExists)
+				}
+			}
+		}
+	}
+	c.values = append(c.values, items...)
+	return nil
+}
+
 // Invalidate invalidates a value @ context.
 // The value will be removed from context and needs to be
 // re-required. Invalidation might e.g. happen during
 // some value changes due to login or register.
-// You can invalidate multiple values at once
+// You can invalidate multiple values at once;
+// keyed values of the given types will be invalidated for all keys.
 
 func (c *Context) Invalidate(rt ...reflect.Type) {
+	if err := c.checkFinalized("invalidate"); err != nil {
+		panic(err)
+	}
 	toInvalidate := map[reflect.Type]struct{}{}
 	for _, v := range rt {
 		toInvalidate[v] = struct{}{}
 	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	vals := []*valueItem{}
 	for _, v := range c.values {
-		if _, ok := toInvalidate[v.rt]; ok {
+		if _, ok := toInvalidate[v.rt]; ok && v.valid {
 			continue
+		} else if ok {
+			// the value is being provisioned concurrently;
+			// it will be removed once provisioned
+			v.invalidated = true
 		}
 		vals = append(vals, v)
 	}
 	c.values = vals
 }
 
+// complete stores the provisioned value within its placeholder
+func (c *Context) complete(item *valueItem, val any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item.val = val
+	item.valid = true
+	if item.invalidated {
+		c.removeValueUnlocked(item)
+	}
+	close(item.done)
+}
+
+// abandon removes the placeholder of a failed provisioning
+// and releases the goroutines waiting for it
+func (c *Context) abandon(item *valueItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeValueUnlocked(item)
+	close(item.done)
+}
+
+// awaitUnlocked returns the stored item matching the predicate while c.mu is held.
+// Items being provisioned concurrently are waited for; in case the item is
+// provisioned by the calling chain of providers itself, we hit a recursion loop.
+// Returns with c.mu held unless an error is returned.
+func (c *Context) awaitUnlocked(rt reflect.Type, match func(v *valueItem) bool) (*valueItem, error) {
+	for {
+		var item *valueItem
+		for _, v := range c.values {
+			if match(v) {
+				item = v
+				break
+			}
+		}
+		if item == nil || item.valid {
+			return item, nil
+		}
+		if c.provisions(item) {
+			c.mu.Unlock()
+			return nil, c.debugRecursionLoop(rt)
+		}
+		done := item.done
+		c.mu.Unlock()
+		<-done
+		c.mu.Lock()
+	}
+}
+
+// provisions returns true in case the placeholder is being provisioned
+// by the chain of providers the context has been handed to
+func (c *Context) provisions(item *valueItem) bool {
+	for ctx := c; ctx != nil; ctx = ctx.caller {
+		if ctx.provisioning == item {
+			return true
+		}
+	}
+	return false
+}
+
+// provisioningView returns the context handed to the provider of the given placeholder;
+// the view shares all values with c
+func (c *Context) provisioningView(item *valueItem) *Context {
+	return &Context{
+		contextState: c.contextState,
+		parent:       c.parent,
+		provisioning: item,
+		caller:       c,
+	}
+}
+
+// withParent returns a context sharing all values with c
+// while using a different parent
+func (c *Context) withParent(parent context.Context) *Context {
+	return &Context{
+		contextState: c.contextState,
+		parent:       parent,
+		provisioning: c.provisioning,
+		caller:       c.caller,
+	}
+}
+
+// StoredTypes returns the types of all values provisioned or stored so far
+// in the order their provisioning started; keyed values are listed once per type
+func (c *Context) StoredTypes() []reflect.Type {
+	return c.types(true)
+}
+
+// InProgressTypes returns the types of all values currently being provisioned
+// in the order their provisioning started, i.e. the chain of types waiting for
+// their dependencies. Useful for diagnosing hanging or recursive provisioning.
+func (c *Context) InProgressTypes() []reflect.Type {
+	return c.types(false)
+}
+
+func (c *Context) types(valid bool) []reflect.Type {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := []reflect.Type{}
+	seen := map[reflect.Type]struct{}{}
+	for _, v := range c.values {
+		if v.valid != valid {
+			continue
+		}
+		if _, ok := seen[v.rt]; ok {
+			continue
+		}
+		seen[v.rt] = struct{}{}
+		out = append(out, v.rt)
+	}
+	return out
+}
+
+// lookup returns a stored value without provisioning it
+func (c *Context) lookup(rt reflect.Type) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, v := range c.values {
+		if v.rt == rt && v.valid && !v.keyed {
+			return v.val, true
+		}
+	}
+	return nil, false
+}
+
 func (c *Context) debugRecursionLoop(inst reflect.Type) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	out := []string{}
 	for _, v := range c.values {
 		if !v.valid {
@@ -92,56 +474,282 @@ func (c *Context) debugRecursionLoop(inst reflect.Type) error {
 	return fmt.Errorf("recursion loop while resolving %v:\n-----------\n%s\n-------------\n%s", inst, strings.Join(out, "\n--> "), string(stackTrace))
 }
 
+// provisionLimitError returns the error including the last provisioned types
+func (c *Context) provisionLimitError(inst reflect.Type) error {
+	const maxTypes = 5
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	types := []reflect.Type{}
+	for i := max(0, len(c.values)-maxTypes+1); i < len(c.values); i++ {
+		types = append(types, c.values[i].rt)
+	}
+	return &ProvisionLimitError{
+		Count: c.provisioned,
+		Types: append(types, inst),
+	}
+}
+
 // func (c *Context) Require[T any]() T {
 func (c *Context) Require(inst reflect.Type) any {
-	if c.finalized {
-		panic(errors.New("context is already finalized"))
+	if err := c.checkFinalized("require " + inst.String()); err != nil {
+		panic(err)
 	}
 	if (inst.Kind() != reflect.Ptr || inst.Elem().Kind() != reflect.Struct) && inst.Kind() != reflect.Interface {
 		panic(errors.New("inst must either be a ptr or an interface"))
 	}
+	if inst == TypeContext {
+		// keep the chain of providers
+		return c
+	}
 
-	for i := range c.values {
-		if c.values[i].rt == inst {
-			if !c.values[i].valid {
-				panic(c.debugRecursionLoop(inst))
-			}
-			return c.values[i].val
+	v, val, err := c.reserve(inst)
+	if err != nil {
+		panic(err)
+	}
+	if v == nil {
+		return val
+	}
+
+	val = c.provision(v, inst)
+
+	c.mu.Lock()
+	taps := c.taps
+	c.mu.Unlock()
+	for _, fn := range taps {
+		fn(inst, val)
+	}
+	return val
+}
+
+// provision provisions the value of the reserved placeholder;
+// the placeholder will be abandoned in case provisioning fails
+func (c *Context) provision(v *valueItem, inst reflect.Type) (val any) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.abandon(v)
+			c.observeProvisionFailure(inst, r)
+			panic(r)
 		}
+	}()
+
+	switch {
+	case v.shared:
+		// shareable values are provisioned once per connection
+		val = c.shared.require(inst)
+	case v.singleton:
+		// singletons are provisioned once per method handler
+		ref := c.singletons.acquire(inst)
+		c.mu.Lock()
+		c.singletonRefs = append(c.singletonRefs, ref)
+		c.mu.Unlock()
+		val = ref.val
+	default:
+		// try to instantiate
+		val = c.provider.Provide(c.provisioningView(v), inst)
+	}
+	c.complete(v, val)
+	return val
+}
+
+// reserve returns the stored value of the given type; in case there is none,
+// a placeholder for the value which is about to be provisioned will be returned.
+// Values being provisioned by other goroutines are waited for.
+func (c *Context) reserve(inst reflect.Type) (*valueItem, any, error) {
+	c.mu.Lock()
+	item, err := c.awaitUnlocked(inst, func(v *valueItem) bool {
+		return v.rt == inst && !v.keyed
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if item != nil {
+		val := item.val
+		c.mu.Unlock()
+		return nil, val, nil
+	}
+
+	c.provisioned++
+	if c.provisioned > c.provisionLimit {
+		c.mu.Unlock()
+		return nil, nil, c.provisionLimitError(inst)
 	}
 
 	v := &valueItem{
-		rt: inst,
+		rt:     inst,
+		shared: c.shared != nil && isShareable(inst),
+		done:   make(chan struct{}),
 	}
+	v.singleton = !v.shared && c.singletons != nil && isSingleton(inst)
 	c.values = append(c.values, v)
+	c.mu.Unlock()
+	return v, nil, nil
+}
+
+// Tap registers a callback which is invoked with every value
+// right after it has been provisioned by Require, e.g. to debug which
+// values a request touched. Taps must not modify the values; values
+// which had been provisioned before registering the tap will not be reported.
+// Taps are invoked in registration order.
+func (c *Context) Tap(fn func(rt reflect.Type, val any)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// copy on write: Require iterates the taps without holding the lock
+	c.taps = append(c.taps[:len(c.taps):len(c.taps)], fn)
+}
+
+// ProvisionAll provisions all given types and returns the time it took
+// to provision each of them, e.g. to profile slow dependencies.
+// Provisioning stops on the first error; the timings of the
+// types provisioned so far are returned nonetheless.
+// Types which had been provisioned before will be reported with
+// the (negligible) time of their lookup.
+func (c *Context) ProvisionAll(types []reflect.Type) (map[reflect.Type]time.Duration, error) {
+	timings := make(map[reflect.Type]time.Duration, len(types))
+	for _, rt := range types {
+		start := c.clock.Now()
+		err := func() (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = getRecoverError(r)
+				}
+			}()
+			c.Require(rt)
+			return nil
+		}()
+		if err != nil {
+			return timings, fmt.Errorf("failed to provision %s: %w", rt, err)
+		}
+		timings[rt] = c.clock.Now().Sub(start)
+	}
+	return timings, nil
+}
 
-	// try to instantiate
-	v.val = c.provider.Provide(c, inst)
-	v.valid = true
-	return v.val
+// TypeOf returns the reflect type of T.
+// The function also works with instantiated generic types,
+// e.g. TypeOf[*Repository[User]]() and TypeOf[*Repository[Order]]()
+// return two distinct types.
+func TypeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Require is the generic version of ctx.Require(); T must either be
+// a ptr to a struct or an interface.
+func Require[T any](ctx *Context) T {
+	if v := ctx.Require(TypeOf[T]()); v != nil {
+		return v.(T)
+	}
+	var zero T
+	return zero
+}
+
+// RequireConcrete requires the interface I and asserts the resolved
+// implementation to be of the concrete type C, e.g. to verify a staging
+// deployment uses the real database instead of a mock:
+//
+//	db, err := jonson.RequireConcrete[DB, *PostgresDB](ctx)
+//
+// An error is returned in case the implementation is not of type C.
+func RequireConcrete[I, C any](ctx *Context) (C, error) {
+	v := ctx.Require(TypeOf[I]())
+	if c, ok := v.(C); ok {
+		return c, nil
+	}
+	var zero C
+	return zero, fmt.Errorf("require concrete: %s resolved to %T, expected %s", TypeOf[I](), v, TypeOf[C]())
 }
 
 func (c *Context) Finalize(err error) error {
-	if c.finalized {
+	if c.finalized.Swap(true) {
 		return err
 	}
-	c.finalized = true
 
-	var errors []error
+	var (
+		errors []error
+		// types contains the type of the value causing errors[i];
+		// nil for the error passed to finalize
+		types []reflect.Type
+	)
 	if err != nil {
 		errors = append(errors, err)
+		types = append(types, nil)
 	}
 
-	// finalize from end to front
+	// finalize from end to front, lower phases first
+	c.mu.Lock()
+	values := make([]*valueItem, 0, len(c.values))
 	for i := len(c.values) - 1; i >= 0; i-- {
-		if f, ok := c.values[i].val.(Finalizeable); ok {
-			if e := f.Finalize(errors); e != nil {
+		if !c.values[i].shared && !c.values[i].singleton && !c.values[i].borrowed {
+			values = append(values, c.values[i])
+		}
+	}
+	c.values = nil
+	c.mu.Unlock()
+	sort.SliceStable(values, func(i, j int) bool {
+		return finalizePhase(values[i].val) < finalizePhase(values[j].val)
+	})
+	for _, v := range values {
+		if e := c.handleFinalizeError(v.rt, c.checkPendingWrites(v.val, errors)); e != nil {
+			errors = append(errors, e)
+			types = append(types, v.rt)
+		}
+		if f, ok := v.val.(Finalizeable); ok {
+			e := f.Finalize(errors)
+			if c.recordFinalize {
+				c.finalizeRecords = append(c.finalizeRecords, FinalizeRecord{
+					Type:  v.rt,
+					Phase: finalizePhase(v.val),
+					Err:   e,
+				})
+			}
+			if e := c.handleFinalizeError(v.rt, e); e != nil {
 				errors = append(errors, e)
+				types = append(types, v.rt)
 			}
 		}
 	}
-	c.values = nil
 
+	if c.attrs.owner == c.contextState {
+		c.attrs.clear()
+	}
+
+	// replaced singletons are finalized once released by their last context
+	c.mu.Lock()
+	refs := c.singletonRefs
+	c.singletonRefs = nil
+	c.mu.Unlock()
+	for _, ref := range refs {
+		ref.release()
+	}
+
+	err = c.finalizeError(err, errors, types)
+	for _, fn := range c.afterFinalize {
+		fn(err)
+	}
+	return err
+}
+
+// RecordFinalize enables recording of all values finalized by the context;
+// the records can be retrieved using FinalizeRecords once the context has been finalized,
+// e.g. to verify a transaction has been committed for a specific request.
+func (c *Context) RecordFinalize() {
+	c.recordFinalize = true
+}
+
+// FinalizeRecords returns the values finalized by the context in order
+func (c *Context) FinalizeRecords() []FinalizeRecord {
+	return append([]FinalizeRecord(nil), c.finalizeRecords...)
+}
+
+// AfterFinalize registers a callback which is invoked once all values
+// have been finalized; err is the error returned by Finalize.
+// Use it for side effects which should only happen after e.g. a transaction
+// has been committed.
+func (c *Context) AfterFinalize(fn func(err error)) {
+	c.afterFinalize = append(c.afterFinalize, fn)
+}
+
+// finalizeError remodels all errors collected during finalization
+func (c *Context) finalizeError(err error, errors []error, types []reflect.Type) error {
 	if len(errors) == 0 {
 		return nil
 	}
@@ -156,8 +764,12 @@ func (c *Context) Finalize(err error) error {
 		if e, ok := errors[i].(*Error); ok {
 			errs[i] = e
 		} else {
+			msg := errors[i].Error()
+			if types[i] != nil {
+				msg = "finalize " + types[i].String() + ": " + msg
+			}
 			errs[i] = ErrInternal.CloneWithData(&ErrorData{
-				Debug: c.methodHandler.errorEncoder.Encode(errors[i].Error()),
+				Debug: c.methodHandler.errorEncoder.Encode(msg),
 			})
 		}
 	}
@@ -165,11 +777,33 @@ func (c *Context) Finalize(err error) error {
 	// return error (we might change to a more specific error code here?)
 	return ErrInternal.CloneWithData(&ErrorData{
 		Debug:   c.methodHandler.errorEncoder.Encode("finalization failed"),
-		Details: []*Error{},
+		Details: errs,
 	})
 }
 
+// OnFinalizeError registers a callback which is invoked for each error
+// returned by a value during finalization. The callback receives the type of
+// the failing value and decides whether the error is fatal: returning nil swallows
+// the error (e.g. after logging it), returning an error includes it in the error
+// returned by Finalize. Callbacks are invoked in registration order.
+func (c *Context) OnFinalizeError(fn func(rt reflect.Type, err error) error) {
+	c.onFinalizeError = append(c.onFinalizeError, fn)
+}
+
+func (c *Context) handleFinalizeError(rt reflect.Type, err error) error {
+	for _, fn := range c.onFinalizeError {
+		if err == nil {
+			break
+		}
+		err = fn(rt, err)
+	}
+	return err
+}
+
 func (c *Context) CallMethod(method string, payload any, bindata []byte) (any, error) {
+	if err := c.checkFinalized("call " + method); err != nil {
+		return nil, err
+	}
 	v, err := c.methodHandler.CallMethod(c, method, payload, bindata)
 	if err != nil {
 		return nil, err
@@ -180,6 +814,71 @@ func (c *Context) CallMethod(method string, payload any, bindata []byte) (any, e
 	return nil, nil
 }
 
+// UnboundedBudget is returned by Budget in case the context has no deadline
+const UnboundedBudget = time.Duration(math.MaxInt64)
+
+// Elapsed returns the time passed since the context has been created
+func (c *Context) Elapsed() time.Duration {
+	return c.clock.Now().Sub(c.started)
+}
+
+// RemainingTime returns the time left until the context's deadline is reached
+// relative to the context's clock.
+// false will be returned in case the context does not have a deadline.
+func (c *Context) RemainingTime() (time.Duration, bool) {
+	deadline, ok := c.Deadline()
+	if !ok {
+		return 0, false
+	}
+	remaining := deadline.Sub(c.clock.Now())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
+
+// Budget divides the remaining time of the context into n equal slices.
+// The slices can be used to set timeouts of sub operations (e.g. downstream calls)
+// so a single sub operation does not consume the whole remaining time.
+// In case the context has no deadline, all slices will be UnboundedBudget.
+// Nil is returned for n <= 0.
+func (c *Context) Budget(n int) []time.Duration {
+	if n <= 0 {
+		return nil
+	}
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return c.WeightedBudget(weights...)
+}
+
+// WeightedBudget divides the remaining time of the context proportionally
+// to the given weights.
+// In case the context has no deadline, all slices will be UnboundedBudget.
+func (c *Context) WeightedBudget(weights ...float64) []time.Duration {
+	out := make([]time.Duration, len(weights))
+	remaining, ok := c.RemainingTime()
+	if !ok {
+		for i := range out {
+			out[i] = UnboundedBudget
+		}
+		return out
+	}
+
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return out
+	}
+	for i, w := range weights {
+		out[i] = time.Duration(float64(remaining) * w / total)
+	}
+	return out
+}
+
 // methods below are for fulfilling the go library context.Context interface
 
 var _ (context.Context) = (*Context)(nil)
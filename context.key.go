@@ -0,0 +1,45 @@
+package jonson
+
+import "context"
+
+// ContextKey is a type safe key of values passed through the
+// standard library context embedded within the Context:
+//
+//	var tenantKey = jonson.NewContextKey[string]("tenant")
+//
+//	ctx = tenantKey.Set(ctx, "acme")
+//	tenant, ok := tenantKey.Get(ctx)
+//
+// Each key instance is unique, two keys of the same type never collide.
+// Use context keys for plain values which should neither be provisioned
+// nor finalized (e.g. values extracted by a middleware or passed to
+// libraries only accepting a context.Context); use Require and providers
+// for dependencies.
+type ContextKey[T any] struct {
+	name string
+}
+
+// NewContextKey returns a new key; the name is used for debugging only
+func NewContextKey[T any](name string) *ContextKey[T] {
+	return &ContextKey[T]{
+		name: name,
+	}
+}
+
+// Set returns a context carrying the given value. The returned context
+// shares all values (e.g. the http request) with c, finalizing either of them
+// finalizes both; only the embedded context.Context differs.
+func (k *ContextKey[T]) Set(c *Context, v T) *Context {
+	return c.withParent(context.WithValue(c.parent, k, v))
+}
+
+// Get returns the value of the key; false is returned
+// in case the value has not been set
+func (k *ContextKey[T]) Get(c context.Context) (T, bool) {
+	v, ok := c.Value(k).(T)
+	return v, ok
+}
+
+func (k *ContextKey[T]) String() string {
+	return "jonson context key " + k.name
+}
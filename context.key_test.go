@@ -0,0 +1,77 @@
+package jonson
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+type contextKeyTestRepo struct {
+	finalized bool
+}
+
+func (r *contextKeyTestRepo) Finalize(errs []error) error {
+	r.finalized = true
+	return nil
+}
+
+func TestContextKey(t *testing.T) {
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *contextKeyTestRepo {
+		return &contextKeyTestRepo{}
+	})
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	tenantKey := NewContextKey[string]("tenant")
+	localeKey := NewContextKey[string]("locale")
+
+	t.Run("expect keys of the same type not to collide", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		ctx = tenantKey.Set(ctx, "acme")
+		ctx = localeKey.Set(ctx, "de-CH")
+
+		if v, ok := tenantKey.Get(ctx); !ok || v != "acme" {
+			t.Fatalf("expected tenant acme, got: %q", v)
+		}
+		if v, ok := localeKey.Get(ctx); !ok || v != "de-CH" {
+			t.Fatalf("expected locale de-CH, got: %q", v)
+		}
+	})
+
+	t.Run("expect missing values to be reported", func(t *testing.T) {
+		ctx := tenantKey.Set(NewContext(context.Background(), fac, mh), "acme")
+		if v, ok := localeKey.Get(ctx); ok {
+			t.Fatalf("expected no locale, got: %q", v)
+		}
+	})
+
+	t.Run("expect values of the context to be shared", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		req := httptest.NewRequest("GET", "/", nil)
+		ctx.StoreValue(TypeHTTPRequest, req)
+		repo := Require[*contextKeyTestRepo](ctx)
+
+		tenant := tenantKey.Set(ctx, "acme")
+		if RequireHttpRequest(tenant) != req {
+			t.Fatal("expected http request to be shared")
+		}
+		if Require[*contextKeyTestRepo](tenant) != repo {
+			t.Fatal("expected provisioned values to be shared")
+		}
+		if _, ok := tenantKey.Get(ctx); ok {
+			t.Fatal("expected original context not to carry the value")
+		}
+
+		ctx.Finalize(nil)
+		if !repo.finalized {
+			t.Fatal("expected shared values to be finalized once")
+		}
+	})
+
+	t.Run("expect values to be visible to forks", func(t *testing.T) {
+		ctx := tenantKey.Set(NewContext(context.Background(), fac, mh), "acme")
+		if v, ok := tenantKey.Get(ctx.Fork()); !ok || v != "acme" {
+			t.Fatalf("expected tenant acme, got: %q", v)
+		}
+	})
+}
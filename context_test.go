@@ -0,0 +1,795 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type contextTestA struct{}
+type contextTestB struct{}
+type contextTestC struct{}
+
+var (
+	typeContextTestA = reflect.TypeOf((**contextTestA)(nil)).Elem()
+	typeContextTestB = reflect.TypeOf((**contextTestB)(nil)).Elem()
+	typeContextTestC = reflect.TypeOf((**contextTestC)(nil)).Elem()
+)
+
+type contextTestProvider struct{}
+
+func (p *contextTestProvider) NewContextTestA(ctx *Context) *contextTestA {
+	return &contextTestA{}
+}
+
+func (p *contextTestProvider) NewContextTestB(ctx *Context) *contextTestB {
+	ctx.Require(typeContextTestA)
+	return &contextTestB{}
+}
+
+func (p *contextTestProvider) NewContextTestC(ctx *Context) *contextTestC {
+	ctx.Require(typeContextTestB)
+	return &contextTestC{}
+}
+
+func newContextTestFactory() *Factory {
+	fac := NewFactory()
+	fac.RegisterProvider(&contextTestProvider{})
+	return fac
+}
+
+func TestContextProvisionLimit(t *testing.T) {
+	fac := newContextTestFactory()
+
+	t.Run("expect provisioning within limit to succeed", func(t *testing.T) {
+		mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+		mh.SetProvisionLimit(3)
+		ctx := NewContext(context.Background(), fac, mh)
+		ctx.Require(typeContextTestC)
+	})
+
+	t.Run("expect provisioning past limit to fail", func(t *testing.T) {
+		mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+		mh.SetProvisionLimit(2)
+		ctx := NewContext(context.Background(), fac, mh)
+
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ErrProvisionLimitExceeded) {
+				t.Fatalf("expected ErrProvisionLimitExceeded, got: %v", err)
+			}
+			var limitErr *ProvisionLimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("expected ProvisionLimitError, got: %T", err)
+			}
+			if limitErr.Count != 3 {
+				t.Fatalf("expected count to be 3, got: %d", limitErr.Count)
+			}
+			if last := limitErr.Types[len(limitErr.Types)-1]; last != typeContextTestA {
+				t.Fatalf("expected last type to be contextTestA, got: %v", last)
+			}
+		}()
+		ctx.Require(typeContextTestC)
+	})
+}
+
+func TestContextBudget(t *testing.T) {
+	fac := newContextTestFactory()
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	t.Run("expect slices to sum up to the remaining time", func(t *testing.T) {
+		parent, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		ctx := NewContext(parent, fac, mh)
+
+		budget := ctx.Budget(3)
+		if len(budget) != 3 {
+			t.Fatalf("expected 3 slices, got: %d", len(budget))
+		}
+		var sum time.Duration
+		for _, v := range budget {
+			sum += v
+		}
+		if sum > time.Second || sum < 900*time.Millisecond {
+			t.Fatalf("expected slices to sum up to roughly 1s, got: %v", sum)
+		}
+	})
+
+	t.Run("expect weighted slices to be proportional", func(t *testing.T) {
+		parent, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		ctx := NewContext(parent, fac, mh)
+
+		budget := ctx.WeightedBudget(1, 3)
+		if budget[1] < 2*budget[0] {
+			t.Fatalf("expected second slice to be roughly three times the first, got: %v", budget)
+		}
+	})
+
+	t.Run("expect unbounded slices without deadline", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		for _, v := range ctx.Budget(2) {
+			if v != UnboundedBudget {
+				t.Fatalf("expected unbounded budget, got: %v", v)
+			}
+		}
+	})
+
+	t.Run("expect no slices for non positive counts", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		for _, n := range []int{0, -1} {
+			if budget := ctx.Budget(n); budget != nil {
+				t.Fatalf("expected no slices for %d, got: %v", n, budget)
+			}
+		}
+	})
+}
+
+type contextTestUser struct{}
+type contextTestOrder struct{}
+
+type contextTestRepository[T any] struct {
+	items []T
+}
+
+func TestContextGenericTypes(t *testing.T) {
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestRepository[contextTestUser] {
+		return &contextTestRepository[contextTestUser]{items: []contextTestUser{{}}}
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestRepository[contextTestOrder] {
+		return &contextTestRepository[contextTestOrder]{items: []contextTestOrder{{}, {}}}
+	})
+	ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+
+	t.Run("expect instantiations to be independent dependencies", func(t *testing.T) {
+		if TypeOf[*contextTestRepository[contextTestUser]]() == TypeOf[*contextTestRepository[contextTestOrder]]() {
+			t.Fatal("expected distinct types")
+		}
+		users := Require[*contextTestRepository[contextTestUser]](ctx)
+		orders := Require[*contextTestRepository[contextTestOrder]](ctx)
+		if len(users.items) != 1 || len(orders.items) != 2 {
+			t.Fatalf("expected independent repositories, got %d users and %d orders", len(users.items), len(orders.items))
+		}
+		if Require[*contextTestRepository[contextTestUser]](ctx) != users {
+			t.Fatal("expected repository to be provisioned once")
+		}
+	})
+
+	t.Run("expect generic values to be storable", func(t *testing.T) {
+		type contextTestCache[K comparable, V any] struct {
+			items map[K]V
+		}
+		rt := TypeOf[*contextTestCache[string, int]]()
+		ctx.StoreValue(rt, &contextTestCache[string, int]{})
+		if Require[*contextTestCache[string, int]](ctx) == nil {
+			t.Fatal("expected stored generic value")
+		}
+	})
+}
+
+type contextTestMetrics struct{}
+
+func (c *contextTestMetrics) Finalize(errs []error) error {
+	return errors.New("failed to flush metrics")
+}
+
+type contextTestTx struct{}
+
+func (c *contextTestTx) Finalize(errs []error) error {
+	return errors.New("failed to commit")
+}
+
+func TestContextOnFinalizeError(t *testing.T) {
+	fac := NewFactory()
+	ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+	typeMetrics := TypeOf[*contextTestMetrics]()
+	typeTx := TypeOf[*contextTestTx]()
+	ctx.StoreValue(typeTx, &contextTestTx{})
+	ctx.StoreValue(typeMetrics, &contextTestMetrics{})
+
+	seen := []reflect.Type{}
+	ctx.OnFinalizeError(func(rt reflect.Type, err error) error {
+		seen = append(seen, rt)
+		if rt == typeMetrics {
+			// metrics are not fatal
+			return nil
+		}
+		return err
+	})
+
+	err := ctx.Finalize(nil)
+	if len(seen) != 2 || seen[0] != typeMetrics || seen[1] != typeTx {
+		t.Fatalf("expected callback to be invoked for metrics and tx, got: %v", seen)
+	}
+	rpcErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got: %v", err)
+	}
+	if len(rpcErr.Data.Details) != 1 {
+		t.Fatalf("expected a single propagated error, got: %v", rpcErr.Data.Details)
+	}
+	if debug := rpcErr.Data.Details[0].Data.Debug; debug != "finalize "+typeTx.String()+": failed to commit" {
+		t.Fatalf("expected error to be attributed to tx, got: %s", debug)
+	}
+}
+
+func TestContextFinalized(t *testing.T) {
+	fac := newContextTestFactory()
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+	mh.RegisterSystem(&MethodHandlerTest{})
+
+	expectFinalizedError := func(t *testing.T, err error, op string) {
+		t.Helper()
+		if !errors.Is(err, ErrContextFinalized) {
+			t.Fatalf("expected ErrContextFinalized, got: %v", err)
+		}
+		var finalizedErr *ContextFinalizedError
+		if !errors.As(err, &finalizedErr) || finalizedErr.Op != op {
+			t.Fatalf("expected operation %q, got: %v", op, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		op   string
+		fn   func(ctx *Context)
+	}{
+		{"require", "require " + typeContextTestA.String(), func(ctx *Context) { ctx.Require(typeContextTestA) }},
+		{"store", "store " + typeContextTestA.String(), func(ctx *Context) { ctx.StoreValue(typeContextTestA, &contextTestA{}) }},
+		{"invalidate", "invalidate", func(ctx *Context) { ctx.Invalidate(typeContextTestA) }},
+		{"fork", "fork", func(ctx *Context) { ctx.Fork() }},
+	}
+
+	for _, tt := range tests {
+		t.Run("expect "+tt.name+" to panic on finalized context", func(t *testing.T) {
+			ctx := NewContext(context.Background(), fac, mh)
+			ctx.Finalize(nil)
+
+			defer func() {
+				err, _ := recover().(error)
+				expectFinalizedError(t, err, tt.op)
+			}()
+			tt.fn(ctx)
+		})
+	}
+
+	t.Run("expect call method to fail on finalized context", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		ctx.Finalize(nil)
+
+		_, err := ctx.CallMethod("method-handler-test/echo.v1", map[string]any{"name": "Silvio"}, nil)
+		expectFinalizedError(t, err, "call method-handler-test/echo.v1")
+	})
+
+	t.Run("expect goroutines outliving the request to fail without racing finalize", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		done := make(chan error)
+		go func() {
+			defer func() {
+				err, _ := recover().(error)
+				done <- err
+			}()
+			for {
+				ctx.Invalidate(typeContextTestA)
+				ctx.Require(typeContextTestA)
+			}
+		}()
+		ctx.Finalize(nil)
+		if err := <-done; !errors.Is(err, ErrContextFinalized) {
+			t.Fatalf("expected ErrContextFinalized, got: %v", err)
+		}
+	})
+}
+
+type contextTestPhased struct {
+	name  string
+	phase int
+	order *[]string
+}
+
+func (c *contextTestPhased) FinalizePhase() int {
+	return c.phase
+}
+
+func (c *contextTestPhased) Finalize(errs []error) error {
+	*c.order = append(*c.order, c.name)
+	return nil
+}
+
+type contextTestUnphased struct {
+	name  string
+	order *[]string
+}
+
+func (c *contextTestUnphased) Finalize(errs []error) error {
+	*c.order = append(*c.order, c.name)
+	return nil
+}
+
+func TestContextFinalizePhase(t *testing.T) {
+	fac := NewFactory()
+	ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+
+	order := []string{}
+	type (
+		metrics *contextTestPhased
+		conn    *contextTestUnphased
+		buffer  *contextTestPhased
+		cache   *contextTestPhased
+	)
+	ctx.StoreValue(TypeOf[metrics](), &contextTestPhased{name: "metrics", phase: 1, order: &order})
+	ctx.StoreValue(TypeOf[buffer](), &contextTestPhased{name: "buffer", phase: -1, order: &order})
+	ctx.StoreValue(TypeOf[conn](), &contextTestUnphased{name: "conn", order: &order})
+	ctx.StoreValue(TypeOf[cache](), &contextTestPhased{name: "cache", phase: -1, order: &order})
+
+	if err := ctx.Finalize(nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"cache", "buffer", "conn", "metrics"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected finalize order %v, got: %v", expected, order)
+	}
+}
+
+type contextTestClock struct {
+	now time.Time
+}
+
+func (c *contextTestClock) Now() time.Time {
+	return c.now
+}
+
+func TestContextElapsed(t *testing.T) {
+	clock := &contextTestClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	fac := NewFactory()
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+	mh.SetClock(clock)
+
+	t.Run("expect elapsed to advance with the clock", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		if elapsed := ctx.Elapsed(); elapsed != 0 {
+			t.Fatalf("expected 0, got: %v", elapsed)
+		}
+		clock.now = clock.now.Add(150 * time.Millisecond)
+		if elapsed := ctx.Elapsed(); elapsed != 150*time.Millisecond {
+			t.Fatalf("expected 150ms, got: %v", elapsed)
+		}
+	})
+
+	t.Run("expect elapsed to work with deadline", func(t *testing.T) {
+		parent, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		ctx := NewContext(parent, fac, mh)
+		clock.now = clock.now.Add(time.Second)
+		if elapsed := ctx.Elapsed(); elapsed != time.Second {
+			t.Fatalf("expected 1s, got: %v", elapsed)
+		}
+	})
+}
+
+func TestContextTap(t *testing.T) {
+	fac := newContextTestFactory()
+	ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+
+	first := []reflect.Type{}
+	second := []reflect.Type{}
+	ctx.Tap(func(rt reflect.Type, val any) {
+		if reflect.TypeOf(val) != rt {
+			t.Fatalf("expected value of type %v, got: %T", rt, val)
+		}
+		first = append(first, rt)
+	})
+	ctx.Tap(func(rt reflect.Type, val any) {
+		if len(second) >= len(first) {
+			t.Fatal("expected taps to run in registration order")
+		}
+		second = append(second, rt)
+	})
+
+	ctx.Require(typeContextTestC)
+	ctx.Require(typeContextTestB)
+	ctx.Require(typeContextTestC)
+
+	// values are reported once they are provisioned, dependencies first
+	expected := []reflect.Type{typeContextTestA, typeContextTestB, typeContextTestC}
+	if !reflect.DeepEqual(first, expected) || !reflect.DeepEqual(second, expected) {
+		t.Fatalf("expected taps to see %v once, got: %v and %v", expected, first, second)
+	}
+}
+
+func TestContextFinalizeRecords(t *testing.T) {
+	fac := NewFactory()
+	ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+	ctx.RecordFinalize()
+
+	order := []string{}
+	type (
+		metrics *contextTestPhased
+		buffer  *contextTestPhased
+	)
+	typeTx := TypeOf[*contextTestTx]()
+	ctx.StoreValue(TypeOf[metrics](), &contextTestPhased{name: "metrics", phase: 1, order: &order})
+	ctx.StoreValue(typeTx, &contextTestTx{})
+	ctx.StoreValue(TypeOf[buffer](), &contextTestPhased{name: "buffer", phase: -1, order: &order})
+
+	var records []FinalizeRecord
+	ctx.AfterFinalize(func(err error) {
+		records = ctx.FinalizeRecords()
+	})
+	ctx.Finalize(nil)
+
+	expected := []struct {
+		rt    reflect.Type
+		phase int
+		err   bool
+	}{
+		{TypeOf[buffer](), -1, false},
+		{typeTx, DefaultFinalizePhase, true},
+		{TypeOf[metrics](), 1, false},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records, got: %v", len(expected), records)
+	}
+	for i, e := range expected {
+		if records[i].Type != e.rt || records[i].Phase != e.phase || (records[i].Err != nil) != e.err {
+			t.Fatalf("expected record %d to be %v, got: %+v", i, e, records[i])
+		}
+	}
+
+	t.Run("expect no records unless enabled", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+		ctx.StoreValue(typeTx, &contextTestTx{})
+		ctx.Finalize(nil)
+		if records := ctx.FinalizeRecords(); len(records) != 0 {
+			t.Fatalf("expected no records, got: %v", records)
+		}
+	})
+}
+
+func TestContextMerge(t *testing.T) {
+	fac := newContextTestFactory()
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	t.Run("expect values to be merged and finalized by source", func(t *testing.T) {
+		source := NewContext(context.Background(), fac, mh)
+		order := []string{}
+		type tx *contextTestUnphased
+		source.StoreValue(TypeOf[tx](), &contextTestUnphased{name: "tx", order: &order})
+		a := source.Require(typeContextTestA)
+
+		ctx := NewContext(context.Background(), fac, mh)
+		if err := ctx.Merge(source, typeContextTestA, TypeOf[tx]()); err != nil {
+			t.Fatal(err)
+		}
+		if ctx.Require(typeContextTestA) != a {
+			t.Fatal("expected merged value")
+		}
+
+		ctx.Finalize(nil)
+		if len(order) != 0 {
+			t.Fatal("expected borrowed value not to be finalized")
+		}
+		source.Finalize(nil)
+		if len(order) != 1 {
+			t.Fatal("expected source to finalize the value")
+		}
+	})
+
+	t.Run("expect collisions to be detected", func(t *testing.T) {
+		source := NewContext(context.Background(), fac, mh)
+		source.Require(typeContextTestB)
+
+		ctx := NewContext(context.Background(), fac, mh)
+		ctx.Require(typeContextTestA)
+		if err := ctx.Merge(source, typeContextTestB, typeContextTestA); !errors.Is(err, ErrValueExists) {
+			t.Fatalf("expected ErrValueExists, got: %v", err)
+		}
+		if _, ok := ctx.lookup(typeContextTestB); ok {
+			t.Fatal("expected no values to be merged on collision")
+		}
+	})
+
+	t.Run("expect missing values to fail", func(t *testing.T) {
+		source := NewContext(context.Background(), fac, mh)
+		ctx := NewContext(context.Background(), fac, mh)
+		if err := ctx.Merge(source, typeContextTestC); err == nil {
+			t.Fatal("expected merge of missing value to fail")
+		}
+	})
+}
+
+type contextTestSlow struct{}
+type contextTestFast struct{}
+type contextTestBroken struct{}
+
+func TestContextProvisionAll(t *testing.T) {
+	clock := &contextTestClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestSlow {
+		clock.now = clock.now.Add(200 * time.Millisecond)
+		return &contextTestSlow{}
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestFast {
+		clock.now = clock.now.Add(10 * time.Millisecond)
+		return &contextTestFast{}
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestBroken {
+		panic(errors.New("broken"))
+	})
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+	mh.SetClock(clock)
+
+	t.Run("expect all types to be provisioned and timed", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		timings, err := ctx.ProvisionAll([]reflect.Type{TypeOf[*contextTestSlow](), TypeOf[*contextTestFast]()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[reflect.Type]time.Duration{
+			TypeOf[*contextTestSlow](): 200 * time.Millisecond,
+			TypeOf[*contextTestFast](): 10 * time.Millisecond,
+		}
+		if !reflect.DeepEqual(timings, expected) {
+			t.Fatalf("expected timings %v, got: %v", expected, timings)
+		}
+		if _, ok := ctx.lookup(TypeOf[*contextTestSlow]()); !ok {
+			t.Fatal("expected value to be provisioned")
+		}
+	})
+
+	t.Run("expect provisioning to stop on the first error", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		timings, err := ctx.ProvisionAll([]reflect.Type{TypeOf[*contextTestFast](), TypeOf[*contextTestBroken](), TypeOf[*contextTestSlow]()})
+		if err == nil {
+			t.Fatal("expected provisioning to fail")
+		}
+		if len(timings) != 1 || timings[TypeOf[*contextTestFast]()] != 10*time.Millisecond {
+			t.Fatalf("expected timing of the first type only, got: %v", timings)
+		}
+		if _, ok := ctx.lookup(TypeOf[*contextTestSlow]()); ok {
+			t.Fatal("expected remaining types not to be provisioned")
+		}
+	})
+}
+
+type contextTestChainA struct{ b *contextTestChainB }
+type contextTestChainB struct{ c *contextTestChainC }
+type contextTestChainC struct{}
+type contextTestBuilding struct{ n int }
+type contextTestLoop struct{}
+type contextTestLoopA struct{}
+type contextTestLoopB struct{}
+
+func TestContextConcurrentInvalidate(t *testing.T) {
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestChainA {
+		return &contextTestChainA{b: Require[*contextTestChainB](ctx)}
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestChainB {
+		return &contextTestChainB{c: Require[*contextTestChainC](ctx)}
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestChainC {
+		return &contextTestChainC{}
+	})
+	var (
+		slowMu    sync.Mutex
+		slowCalls int
+		slowStart = make(chan struct{})
+	)
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestBuilding {
+		slowMu.Lock()
+		slowCalls++
+		n := slowCalls
+		slowMu.Unlock()
+		close(slowStart)
+		time.Sleep(20 * time.Millisecond)
+		return &contextTestBuilding{n: n}
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestLoop {
+		return Require[*contextTestLoop](ctx)
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestLoopA {
+		Require[*contextTestLoopB](ctx)
+		return &contextTestLoopA{}
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestLoopB {
+		// the context required by the provider keeps the provisioning chain
+		Require[*contextTestLoopA](Require[*Context](ctx))
+		return &contextTestLoopB{}
+	})
+	ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+
+	t.Run("expect recursion within the same provisioning chain to be reported", func(t *testing.T) {
+		_, err := ctx.ProvisionAll([]reflect.Type{TypeOf[*contextTestLoop]()})
+		if err == nil || !strings.Contains(err.Error(), "recursion loop") {
+			t.Fatalf("expected recursion loop, got: %v", err)
+		}
+		_, err = ctx.ProvisionAll([]reflect.Type{TypeOf[*contextTestLoopA]()})
+		if err == nil || !strings.Contains(err.Error(), "recursion loop") {
+			t.Fatalf("expected recursion loop across dependencies, got: %v", err)
+		}
+	})
+
+	t.Run("expect concurrent require of the same type to wait for the ongoing provisioning", func(t *testing.T) {
+		results := make(chan *contextTestBuilding, 1)
+		go func() {
+			results <- Require[*contextTestBuilding](ctx)
+		}()
+		<-slowStart
+
+		// the placeholder exists while the first goroutine is still provisioning
+		second := Require[*contextTestBuilding](ctx)
+		first := <-results
+		if first != second || first.n != 1 {
+			t.Fatalf("expected a single provisioning, got: %d and %d", first.n, second.n)
+		}
+	})
+
+	t.Run("expect invalidate not to corrupt ongoing provisioning", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 1000; i++ {
+				ctx.Invalidate(TypeOf[*contextTestChainB](), TypeOf[*contextTestChainC]())
+			}
+		}()
+
+		for i := 0; i < 1000; i++ {
+			ctx.Invalidate(TypeOf[*contextTestChainA]())
+			if a := Require[*contextTestChainA](ctx); a.b == nil || a.b.c == nil {
+				t.Fatal("expected dependency chain to be provisioned")
+			}
+		}
+		<-done
+
+		seen := map[reflect.Type]bool{}
+		for _, v := range ctx.values {
+			if seen[v.rt] || !v.valid {
+				t.Fatalf("expected a single valid value of %v", v.rt)
+			}
+			seen[v.rt] = true
+		}
+		if err := ctx.Finalize(nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+type contextTestStore interface {
+	Name() string
+}
+
+type contextTestRealStore struct{}
+
+func (s *contextTestRealStore) Name() string { return "real" }
+
+type contextTestMockStore struct{}
+
+func (s *contextTestMockStore) Name() string { return "mock" }
+
+func TestRequireConcrete(t *testing.T) {
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) contextTestStore {
+		return &contextTestRealStore{}
+	})
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	t.Run("expect matching implementation to be returned", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		store, err := RequireConcrete[contextTestStore, *contextTestRealStore](ctx)
+		if err != nil || store == nil || store.Name() != "real" {
+			t.Fatalf("expected real store, got: %v %v", store, err)
+		}
+	})
+
+	t.Run("expect mismatching implementation to fail", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		store, err := RequireConcrete[contextTestStore, *contextTestMockStore](ctx)
+		if err == nil || store != nil {
+			t.Fatalf("expected error, got: %v", store)
+		}
+		if !strings.Contains(err.Error(), "*jonson.contextTestRealStore") {
+			t.Fatalf("expected error to name the resolved type, got: %s", err)
+		}
+	})
+}
+
+func TestContextDetach(t *testing.T) {
+	fac := newContextTestFactory()
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	t.Run("expect detached context to survive the parent's finalize", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		ctx.Require(typeContextTestA)
+
+		detached, cancel := ctx.Detach(time.Minute)
+		defer cancel()
+		if err := ctx.Finalize(nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := detached.Err(); err != nil {
+			t.Fatalf("expected detached context to be alive, got: %s", err)
+		}
+		if _, ok := detached.lookup(typeContextTestA); ok {
+			t.Fatal("expected detached context not to inherit the request's values")
+		}
+		detached.Require(typeContextTestA)
+		if err := detached.Finalize(nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("expect detached context to use its own deadline", func(t *testing.T) {
+		parent, parentCancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer parentCancel()
+		ctx := NewContext(parent, fac, mh)
+
+		detached, cancel := ctx.Detach(time.Hour)
+		defer cancel()
+		<-ctx.Done()
+
+		deadline, ok := detached.Deadline()
+		if !ok || time.Until(deadline) < 59*time.Minute {
+			t.Fatalf("expected detached deadline in an hour, got: %s", deadline)
+		}
+		if detached.Err() != nil {
+			t.Fatal("expected detached context not to be cancelled with its parent")
+		}
+
+		cancel()
+		if !errors.Is(detached.Err(), context.Canceled) {
+			t.Fatalf("expected detached context to be cancelled, got: %v", detached.Err())
+		}
+	})
+}
+
+type contextTestOuter struct{}
+type contextTestInner struct {
+	inProgress []reflect.Type
+	stored     []reflect.Type
+}
+
+func TestContextInProgressTypes(t *testing.T) {
+	fac := NewFactory()
+	fac.RegisterProvider(&contextTestProvider{})
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestOuter {
+		Require[*contextTestInner](ctx)
+		return &contextTestOuter{}
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *contextTestInner {
+		ctx.Require(typeContextTestA)
+		return &contextTestInner{
+			inProgress: ctx.InProgressTypes(),
+			stored:     ctx.StoredTypes(),
+		}
+	})
+	ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+
+	t.Run("expect types being provisioned to be listed from within a provider", func(t *testing.T) {
+		Require[*contextTestOuter](ctx)
+		v := Require[*contextTestInner](ctx)
+		expected := []reflect.Type{TypeOf[*contextTestOuter](), TypeOf[*contextTestInner]()}
+		if !reflect.DeepEqual(v.inProgress, expected) {
+			t.Fatalf("expected %v, got: %v", expected, v.inProgress)
+		}
+		if expected := []reflect.Type{TypeContext, typeContextTestA}; !reflect.DeepEqual(v.stored, expected) {
+			t.Fatalf("expected %v to be stored, got: %v", expected, v.stored)
+		}
+	})
+
+	t.Run("expect no types to be in progress once provisioned", func(t *testing.T) {
+		if types := ctx.InProgressTypes(); len(types) != 0 {
+			t.Fatalf("expected no types in progress, got: %v", types)
+		}
+		expected := []reflect.Type{TypeContext, TypeOf[*contextTestOuter](), TypeOf[*contextTestInner](), typeContextTestA}
+		if types := ctx.StoredTypes(); !reflect.DeepEqual(types, expected) {
+			t.Fatalf("expected %v, got: %v", expected, types)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package jonson
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GRPCTimeoutHeader is the header used by grpc to propagate deadlines
+const GRPCTimeoutHeader = "grpc-timeout"
+
+// DeadlineOptions configure the propagation of deadlines
+type DeadlineOptions struct {
+	// Header receives the remaining time; defaults to grpc-timeout
+	Header string
+	// Format formats the remaining time; defaults to FormatGRPCTimeout
+	Format func(d time.Duration) string
+	// MinTimeout is the minimum propagated timeout so near-zero budgets
+	// do not result in absurd values; defaults to 10ms
+	MinTimeout time.Duration
+	// Transport is the underlying transport; defaults to http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// FormatGRPCTimeout formats the duration as grpc timeout, e.g. 1500m
+func FormatGRPCTimeout(d time.Duration) string {
+	// grpc allows at most 8 digits
+	const max = 99999999
+	if ms := d.Milliseconds(); ms <= max {
+		return strconv.FormatInt(ms, 10) + "m"
+	}
+	if s := int64(d / time.Second); s <= max {
+		return strconv.FormatInt(s, 10) + "S"
+	}
+	return strconv.FormatInt(min(int64(d/time.Hour), max), 10) + "H"
+}
+
+// DeadlineClient is an http client propagating the context's
+// remaining time to downstream services. Provide the client using a provider:
+//
+//	fac.RegisterProviderFunc(func(ctx *jonson.Context) *jonson.DeadlineClient {
+//		return jonson.NewDeadlineClient(ctx, nil)
+//	})
+type DeadlineClient struct {
+	*http.Client
+}
+
+func NewDeadlineClient(ctx *Context, options *DeadlineOptions) *DeadlineClient {
+	opts := DeadlineOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Header == "" {
+		opts.Header = GRPCTimeoutHeader
+	}
+	if opts.Format == nil {
+		opts.Format = FormatGRPCTimeout
+	}
+	if opts.MinTimeout <= 0 {
+		opts.MinTimeout = 10 * time.Millisecond
+	}
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+	return &DeadlineClient{
+		Client: &http.Client{
+			Transport: &deadlineTransport{
+				ctx:     ctx,
+				options: &opts,
+			},
+		},
+	}
+}
+
+type deadlineTransport struct {
+	ctx     *Context
+	options *DeadlineOptions
+}
+
+func (d *deadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	remaining, ok := d.ctx.RemainingTime()
+	if !ok {
+		return d.options.Transport.RoundTrip(req)
+	}
+	if remaining < d.options.MinTimeout {
+		remaining = d.options.MinTimeout
+	}
+
+	// round trippers must not modify the original request
+	req = req.Clone(req.Context())
+	req.Header.Set(d.options.Header, d.options.Format(remaining))
+	if _, ok := req.Context().Deadline(); !ok {
+		deadline, _ := d.ctx.Deadline()
+		reqCtx, cancel := context.WithDeadline(req.Context(), deadline)
+		resp, err := d.options.Transport.RoundTrip(req.WithContext(reqCtx))
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
+	}
+	return d.options.Transport.RoundTrip(req)
+}
+
+// cancelBody releases the request's deadline once the body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelBody) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// DeadlineHeader is the header carrying absolute deadlines propagated by clients
+const DeadlineHeader = "X-Request-Deadline"
+
+// ClientDeadlineOptions configure accepting absolute deadlines propagated by clients.
+// Since client and server clocks may be skewed, implausible deadlines are clamped
+// to the range of MinTimeout and MaxTimeout relative to the server's clock.
+type ClientDeadlineOptions struct {
+	// Header receives the deadline formatted as RFC 3339; defaults to X-Request-Deadline
+	Header string
+	// MinTimeout is the minimum time granted to requests whose deadline
+	// already passed or is about to pass; defaults to 100ms
+	MinTimeout time.Duration
+	// MaxTimeout is the maximum time granted to requests; defaults to 1m
+	MaxTimeout time.Duration
+	// LogSkew logs clamped deadlines
+	LogSkew bool
+}
+
+// SetClientDeadlines enables accepting deadlines propagated by http clients;
+// the method's timeout remains in place in case it expires earlier.
+// nil disables client deadlines. Disabled by default.
+func (m *MethodHandler) SetClientDeadlines(options *ClientDeadlineOptions) {
+	if options == nil {
+		m.clientDeadlines = nil
+		return
+	}
+	opts := *options
+	if opts.Header == "" {
+		opts.Header = DeadlineHeader
+	}
+	if opts.MinTimeout <= 0 {
+		opts.MinTimeout = 100 * time.Millisecond
+	}
+	if opts.MaxTimeout <= 0 {
+		opts.MaxTimeout = time.Minute
+	}
+	m.clientDeadlines = &opts
+}
+
+// clientTimeout returns the time left until the deadline propagated
+// by the client, clamped to the configured range
+func (m *MethodHandler) clientTimeout(r *http.Request) (time.Duration, bool) {
+	opts := m.clientDeadlines
+	if opts == nil || r == nil {
+		return 0, false
+	}
+	v := r.Header.Get(opts.Header)
+	if v == "" {
+		return 0, false
+	}
+	deadline, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return 0, false
+	}
+
+	timeout := deadline.Sub(m.clock.Now())
+	clamped := min(max(timeout, opts.MinTimeout), opts.MaxTimeout)
+	if clamped != timeout && opts.LogSkew {
+		log.Printf("method handler: clamped client deadline %s (%v from now) to %v, clocks might be skewed", v, timeout, clamped)
+	}
+	return clamped, true
+}
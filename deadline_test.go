@@ -0,0 +1,139 @@
+package jonson
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeadlineClient(t *testing.T) {
+	fac := NewFactory()
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(GRPCTimeoutHeader)
+	}))
+	defer srv.Close()
+
+	call := func(t *testing.T, ctx *Context, options *DeadlineOptions) string {
+		t.Helper()
+		resp, err := NewDeadlineClient(ctx, options).Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return <-received
+	}
+
+	t.Run("expect header to reflect the remaining time", func(t *testing.T) {
+		parent, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		header := call(t, NewContext(parent, fac, mh), nil)
+		if !strings.HasSuffix(header, "m") {
+			t.Fatalf("expected timeout in milliseconds, got: %s", header)
+		}
+		ms, err := strconv.Atoi(strings.TrimSuffix(header, "m"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ms <= 1000 || ms > 2000 {
+			t.Fatalf("expected timeout between 1000m and 2000m, got: %s", header)
+		}
+	})
+
+	t.Run("expect near-zero budgets to be clamped", func(t *testing.T) {
+		parent, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		header := call(t, NewContext(parent, fac, mh), &DeadlineOptions{MinTimeout: 5 * time.Second})
+		if header != "5000m" {
+			t.Fatalf("expected clamped timeout 5000m, got: %s", header)
+		}
+	})
+
+	t.Run("expect no header without deadline", func(t *testing.T) {
+		if header := call(t, NewContext(context.Background(), fac, mh), nil); header != "" {
+			t.Fatalf("expected no header, got: %s", header)
+		}
+	})
+}
+
+func TestFormatGRPCTimeout(t *testing.T) {
+	for d, expected := range map[time.Duration]string{
+		1500 * time.Millisecond: "1500m",
+		48 * time.Hour:          "172800S",
+		20000 * time.Hour * 24:  "480000H",
+	} {
+		if out := FormatGRPCTimeout(d); out != expected {
+			t.Fatalf("expected %s for %s, got: %s", expected, d, out)
+		}
+	}
+}
+
+func TestClientDeadlines(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.SetClock(clock)
+	mh.SetClientDeadlines(&ClientDeadlineOptions{
+		MinTimeout: 200 * time.Millisecond,
+		MaxTimeout: 10 * time.Second,
+		LogSkew:    true,
+	})
+
+	var remaining time.Duration
+	mh.RegisterMethod(&MethodDefinition{
+		System:  "deadline-test",
+		Method:  "remaining",
+		Version: 1,
+		HandlerFunc: func(ctx *Context) error {
+			remaining, _ = ctx.RemainingTime()
+			return nil
+		},
+	})
+
+	call := func(t *testing.T, deadline string) time.Duration {
+		t.Helper()
+		remaining = 0
+		req := httptest.NewRequest(http.MethodGet, "/deadline-test/remaining.v1", nil)
+		if deadline != "" {
+			req.Header.Set(DeadlineHeader, deadline)
+		}
+		w := httptest.NewRecorder()
+		NewHttpMethodHandler(mh).Handle(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got: %d %s", w.Code, w.Body.String())
+		}
+		return remaining
+	}
+
+	// the fake clock stands still, so the remaining time never shrinks
+	// below the granted timeout but may exceed it by the real time passed
+	expectTimeout := func(t *testing.T, got time.Duration, expected time.Duration) {
+		t.Helper()
+		if got < expected || got > expected+time.Second {
+			t.Fatalf("expected remaining time of %v, got: %v", expected, got)
+		}
+	}
+
+	t.Run("expect plausible deadlines to be accepted", func(t *testing.T) {
+		expectTimeout(t, call(t, clock.Now().Add(5*time.Second).Format(time.RFC3339Nano)), 5*time.Second)
+	})
+
+	t.Run("expect past deadlines to be clamped to the minimum", func(t *testing.T) {
+		expectTimeout(t, call(t, clock.Now().Add(-time.Hour).Format(time.RFC3339Nano)), 200*time.Millisecond)
+	})
+
+	t.Run("expect far future deadlines to be clamped to the maximum", func(t *testing.T) {
+		expectTimeout(t, call(t, clock.Now().AddDate(10, 0, 0).Format(time.RFC3339Nano)), 10*time.Second)
+	})
+
+	t.Run("expect no deadline without header", func(t *testing.T) {
+		if got := call(t, ""); got != 0 {
+			t.Fatalf("expected no deadline, got: %v", got)
+		}
+	})
+}
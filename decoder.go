@@ -0,0 +1,309 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Content types known by jonson
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeForm     = "application/x-www-form-urlencoded"
+	ContentTypeMsgpack  = "application/msgpack"
+	ContentTypeProtobuf = "application/protobuf"
+	// ContentTypeQuery is used for params sent within the url's query string
+	ContentTypeQuery = "application/x-query-string"
+)
+
+// PayloadDecoder decodes a raw payload into the
+// params struct of a remote procedure call
+type PayloadDecoder interface {
+	Decode(data []byte, out any) error
+}
+
+// PayloadDecoderFunc allows us to use a simple function as PayloadDecoder.
+// Since jonson does not ship with a protobuf implementation, a protobuf
+// decoder can be registered as follows:
+//
+//	methodHandler.RegisterPayloadDecoder(jonson.ContentTypeProtobuf, jonson.PayloadDecoderFunc(func(data []byte, out any) error {
+//		return proto.Unmarshal(data, out.(proto.Message))
+//	}))
+type PayloadDecoderFunc func(data []byte, out any) error
+
+func (f PayloadDecoderFunc) Decode(data []byte, out any) error {
+	return f(data, out)
+}
+
+// JSONDecoder decodes json payloads; unknown fields are not allowed
+type JSONDecoder struct{}
+
+func NewJSONDecoder() *JSONDecoder {
+	return &JSONDecoder{}
+}
+
+func (d *JSONDecoder) Decode(data []byte, out any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	return dec.Decode(out)
+}
+
+// MsgpackDecoder decodes msgpack payloads.
+// Field names are taken from the struct's json tags
+// so the same params struct can be used for json and msgpack.
+type MsgpackDecoder struct{}
+
+func NewMsgpackDecoder() *MsgpackDecoder {
+	return &MsgpackDecoder{}
+}
+
+func (d *MsgpackDecoder) Decode(data []byte, out any) error {
+	registerMsgpackOptionals(reflect.TypeOf(out))
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	dec.DisallowUnknownFields(true)
+	return dec.Decode(out)
+}
+
+// FormDecoder decodes url encoded forms.
+// Field names are taken from the struct's json tags,
+// repeated keys are mapped to slice fields.
+type FormDecoder struct{}
+
+func NewFormDecoder() *FormDecoder {
+	return &FormDecoder{}
+}
+
+func (d *FormDecoder) Decode(data []byte, out any) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	return decodeValues(values, out)
+}
+
+// FieldDecodeError is returned whenever a single field could not be decoded
+type FieldDecodeError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldDecodeError) Error() string {
+	return fmt.Sprintf("decode values: field %q: %s", e.Field, e.Err)
+}
+
+func (e *FieldDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeValues assigns the given values to the struct out is pointing to
+func decodeValues(values url.Values, out any) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("decode values: expected ptr to struct")
+	}
+
+	fields := map[string]reflect.Value{}
+	collectFields(rv.Elem(), fields)
+
+	for key, vals := range values {
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("decode values: unknown field %q", key)
+		}
+		if err := setFieldValues(field, vals); err != nil {
+			return &FieldDecodeError{Field: key, Err: err}
+		}
+	}
+	return nil
+}
+
+// collectFields collects all settable fields by their json name;
+// embedded structs will be inlined
+func collectFields(rv reflect.Value, out map[string]reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		rtf := rt.Field(i)
+		if rtf.Anonymous && rtf.Type.Kind() == reflect.Struct {
+			collectFields(rv.Field(i), out)
+			continue
+		}
+		if rtf.PkgPath != "" {
+			// skip private fields
+			continue
+		}
+		name, ok := jsonFieldName(rtf)
+		if !ok {
+			continue
+		}
+		out[name] = rv.Field(i)
+	}
+}
+
+// jsonFieldName returns the name of a field as used within json;
+// returns false in case the field is not exposed to json
+func jsonFieldName(rtf reflect.StructField) (string, bool) {
+	name := rtf.Name
+	if jt, ok := rtf.Tag.Lookup("json"); ok {
+		if p := strings.Split(jt, ","); len(p) > 0 && len(p[0]) > 0 {
+			if p[0] == "-" {
+				return "", false
+			}
+			name = p[0]
+		}
+	}
+	return name, true
+}
+
+var typeDuration = reflect.TypeOf(time.Duration(0))
+
+func setFieldValues(field reflect.Value, vals []string) error {
+	if elem, ok := optionalElem(field.Type()); ok {
+		v := reflect.New(elem).Elem()
+		if err := setFieldValues(v, vals); err != nil {
+			return err
+		}
+		field.Addr().Interface().(optionalSetter).setOptional(v.Interface())
+		return nil
+	}
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(vals), len(vals))
+		for i, v := range vals {
+			if err := setFieldValue(slice.Index(i), v); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	if len(vals) != 1 {
+		return fmt.Errorf("expected a single value, got %d", len(vals))
+	}
+	return setFieldValue(field, vals[0])
+}
+
+func setFieldValue(field reflect.Value, val string) error {
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setFieldValue(ptr.Elem(), val); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	switch field.Type() {
+	case typeTime:
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	case typeDuration:
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(val, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %v", field.Type())
+	}
+	return nil
+}
+
+// requestContentType returns the media type of the given request;
+// an empty string is returned in case no content type was sent
+func requestContentType(r *http.Request) string {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return ct
+	}
+	return mediaType
+}
+
+// acceptsContentType returns true in case the endpoint's params
+// may be decoded from the given content type
+func (endpoint apiEndpoint) acceptsContentType(contentType string) bool {
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+
+	// copy the definition's content types, we must not append to them
+	accepted := append([]string{}, endpoint.def.ContentTypes...)
+	if len(accepted) == 0 {
+		accepted = []string{ContentTypeJSON}
+	}
+	if endpoint.def.QueryParams {
+		accepted = append(accepted, ContentTypeQuery)
+	}
+	for _, v := range accepted {
+		if v == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// payloadDecoder returns the decoder for the given content type
+func (m *MethodHandler) payloadDecoder(contentType string) (PayloadDecoder, error) {
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+	dec, ok := m.decoders[contentType]
+	if !ok {
+		return nil, fmt.Errorf("no decoder registered for content type %s", contentType)
+	}
+	return dec, nil
+}
+
+// RegisterPayloadDecoder registers a decoder for the given content type.
+// Existing decoders will be replaced.
+func (m *MethodHandler) RegisterPayloadDecoder(contentType string, decoder PayloadDecoder) {
+	m.decoders[contentType] = decoder
+}
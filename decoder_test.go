@@ -0,0 +1,264 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type decoderTestParams struct {
+	Params
+	Name  string   `json:"name"`
+	Limit int      `json:"limit"`
+	Tags  []string `json:"tags"`
+}
+
+type DecoderTest struct{}
+
+func (d *DecoderTest) EchoV1(ctx *Context, params *decoderTestParams) (*decoderTestParams, error) {
+	return params, nil
+}
+
+func TestPayloadDecoder(t *testing.T) {
+	expected := &decoderTestParams{
+		Name:  "Silvio",
+		Limit: 10,
+		Tags:  []string{"a", "b"},
+	}
+
+	msgpackPayload, err := msgpack.Marshal(map[string]any{
+		"name":  "Silvio",
+		"limit": 10,
+		"tags":  []string{"a", "b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		decoder PayloadDecoder
+		payload []byte
+	}{
+		{"json", NewJSONDecoder(), []byte(`{"name":"Silvio","limit":10,"tags":["a","b"]}`)},
+		{"msgpack", NewMsgpackDecoder(), msgpackPayload},
+		{"form", NewFormDecoder(), []byte("name=Silvio&limit=10&tags=a&tags=b")},
+	}
+
+	for _, tt := range tests {
+		t.Run("expect "+tt.name+" payload to be decoded", func(t *testing.T) {
+			out := &decoderTestParams{}
+			if err := tt.decoder.Decode(tt.payload, out); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(out, expected) {
+				t.Fatalf("expected %+v, got %+v", expected, out)
+			}
+		})
+	}
+
+	t.Run("expect unknown fields to fail", func(t *testing.T) {
+		if err := NewFormDecoder().Decode([]byte("unknown=1"), &decoderTestParams{}); err == nil {
+			t.Fatal("expected form decoder to fail on unknown field")
+		}
+	})
+}
+
+func TestPayloadDecoderContentType(t *testing.T) {
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterMethod(&MethodDefinition{
+		System:       "decoder-test",
+		Method:       "echo",
+		Version:      1,
+		HandlerFunc:  (&DecoderTest{}).EchoV1,
+		ContentTypes: []string{ContentTypeJSON, ContentTypeMsgpack},
+	})
+	handler := NewHttpMethodHandler(mh)
+
+	msgpackPayload, _ := msgpack.Marshal(map[string]any{"name": "Silvio"})
+
+	tests := []struct {
+		name        string
+		contentType string
+		payload     []byte
+		status      int
+	}{
+		{"default", "", []byte(`{"name":"Silvio"}`), http.StatusOK},
+		{"json", "application/json; charset=utf-8", []byte(`{"name":"Silvio"}`), http.StatusOK},
+		{"msgpack", ContentTypeMsgpack, msgpackPayload, http.StatusOK},
+		{"form not accepted", ContentTypeForm, []byte("name=Silvio"), http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/decoder-test/echo.v1", bytes.NewReader(tt.payload))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			if !handler.Handle(w, req) {
+				t.Fatal("expected request to be handled")
+			}
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAcceptContentTypes(t *testing.T) {
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterMethod(&MethodDefinition{
+		System:      "decoder-test",
+		Method:      "echo",
+		Version:     1,
+		HandlerFunc: (&DecoderTest{}).EchoV1,
+	}, AcceptContentTypes(ContentTypeForm))
+	handler := NewHttpMethodHandler(mh)
+
+	call := func(t *testing.T, contentType string, payload string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/decoder-test/echo.v1", bytes.NewReader([]byte(payload)))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		if !handler.Handle(w, req) {
+			t.Fatal("expected request to be handled")
+		}
+		return w
+	}
+
+	t.Run("expect allowed content type to be decoded", func(t *testing.T) {
+		if w := call(t, ContentTypeForm, "name=Silvio"); w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got: %d %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("expect disallowed content type to be rejected with 415", func(t *testing.T) {
+		w := call(t, ContentTypeJSON, `{"name":"Silvio"}`)
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Fatalf("expected status 415, got: %d %s", w.Code, w.Body.String())
+		}
+		rpcErr := &Error{}
+		if err := json.Unmarshal(w.Body.Bytes(), rpcErr); err != nil {
+			t.Fatal(err)
+		}
+		if rpcErr.Code != ErrUnsupportedContentType.Code {
+			t.Fatalf("expected unsupported content type error, got: %s", w.Body.String())
+		}
+	})
+}
+
+type decoderTestQueryParams struct {
+	Params
+	Limit  int           `json:"limit"`
+	Active bool          `json:"active"`
+	Tags   []string      `json:"tag"`
+	Since  *time.Time    `json:"since"`
+	MaxAge time.Duration `json:"maxAge"`
+}
+
+func (d *DecoderTest) SearchV1(ctx *Context, params *decoderTestQueryParams) (*decoderTestQueryParams, error) {
+	return params, nil
+}
+
+type decoderTestPageParams struct {
+	Params
+	Cursor Optional[string] `json:"cursor"`
+}
+
+func (d *DecoderTest) PageV1(ctx *Context, params *decoderTestPageParams) (string, error) {
+	if v, ok := params.Cursor.Value(); ok {
+		return "cursor:" + v, nil
+	}
+	return "absent", nil
+}
+
+func TestQueryParams(t *testing.T) {
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterMethod(&MethodDefinition{
+		System:      "decoder-test",
+		Method:      "search",
+		Version:     1,
+		HandlerFunc: (&DecoderTest{}).SearchV1,
+	}, QueryParams())
+	mh.RegisterMethod(&MethodDefinition{
+		System:      "decoder-test",
+		Method:      "page",
+		Version:     1,
+		HandlerFunc: (&DecoderTest{}).PageV1,
+	}, QueryParams())
+	handler := NewHttpMethodHandler(mh)
+
+	call := func(t *testing.T, query string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		if !handler.Handle(w, httptest.NewRequest(http.MethodGet, "/decoder-test/search.v1?"+query, nil)) {
+			t.Fatal("expected request to be handled")
+		}
+		return w
+	}
+
+	t.Run("expect query values to be coerced into field types", func(t *testing.T) {
+		w := call(t, "limit=10&active=true&tag=a&tag=b&since=2024-01-02T15:04:05Z&maxAge=5m")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got: %d %s", w.Code, w.Body.String())
+		}
+		out := &decoderTestQueryParams{}
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatal(err)
+		}
+		since := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+		expected := &decoderTestQueryParams{Limit: 10, Active: true, Tags: []string{"a", "b"}, Since: &since, MaxAge: 5 * time.Minute}
+		if !reflect.DeepEqual(out, expected) {
+			t.Fatalf("expected %+v, got %+v", expected, out)
+		}
+	})
+
+	t.Run("expect coercion failures to name the field", func(t *testing.T) {
+		w := call(t, "limit=ten")
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got: %d", w.Code)
+		}
+		rpcErr := &Error{}
+		if err := json.Unmarshal(w.Body.Bytes(), rpcErr); err != nil {
+			t.Fatal(err)
+		}
+		if rpcErr.Code != ErrInvalidParams.Code || rpcErr.Data == nil || !reflect.DeepEqual(rpcErr.Data.Path, []any{"limit"}) {
+			t.Fatalf("expected invalid params for field limit, got: %s", w.Body.String())
+		}
+	})
+
+	t.Run("expect query values to be bound to optional fields", func(t *testing.T) {
+		for query, expected := range map[string]string{
+			"cursor=abc": `"cursor:abc"`,
+			"":           `"absent"`,
+		} {
+			w := httptest.NewRecorder()
+			handler.Handle(w, httptest.NewRequest(http.MethodGet, "/decoder-test/page.v1?"+query, nil))
+			if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != expected {
+				t.Fatalf("expected %s for query %q, got: %d %s", expected, query, w.Code, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("expect methods without query params to reject GET", func(t *testing.T) {
+		mh.RegisterMethod(&MethodDefinition{
+			System:      "decoder-test",
+			Method:      "echo",
+			Version:     1,
+			HandlerFunc: (&DecoderTest{}).EchoV1,
+		})
+		w := httptest.NewRecorder()
+		handler.Handle(w, httptest.NewRequest(http.MethodGet, "/decoder-test/echo.v1?name=Silvio", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("expected status 405, got: %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package jonson
+
+import "net/http"
+
+// ResultEnvelopeHeader is the http header clients use
+// to select the envelope of their results
+const ResultEnvelopeHeader = "X-Result-Envelope"
+
+// Result envelopes shipped with jonson
+const (
+	// ResultEnvelopeBare returns the result as it is (default)
+	ResultEnvelopeBare = "bare"
+	// ResultEnvelopeData wraps the result: {"data": result}
+	ResultEnvelopeData = "data"
+	// ResultEnvelopeMeta wraps the result including response meta data:
+	// {"result": result, "meta": {"warnings": [...], "duration": 1.5}}
+	ResultEnvelopeMeta = "meta"
+)
+
+// ResultEnvelope wraps the result of a method before it is encoded
+type ResultEnvelope interface {
+	Wrap(result any, meta *ResultMeta) any
+}
+
+// ResultEnvelopeFunc allows us to use a function as ResultEnvelope
+type ResultEnvelopeFunc func(result any, meta *ResultMeta) any
+
+func (f ResultEnvelopeFunc) Wrap(result any, meta *ResultMeta) any {
+	return f(result, meta)
+}
+
+// ResultMeta contains the meta data of the response
+type ResultMeta struct {
+	Warnings []*Warning `json:"warnings,omitempty"`
+	// Duration is the server's processing time in milliseconds;
+	// set in case server timing has been enabled, see SetServerTiming
+	Duration *float64 `json:"duration,omitempty"`
+}
+
+func defaultResultEnvelopes() map[string]ResultEnvelope {
+	return map[string]ResultEnvelope{
+		ResultEnvelopeBare: ResultEnvelopeFunc(func(result any, meta *ResultMeta) any {
+			return result
+		}),
+		ResultEnvelopeData: ResultEnvelopeFunc(func(result any, meta *ResultMeta) any {
+			return map[string]any{"data": result}
+		}),
+		ResultEnvelopeMeta: ResultEnvelopeFunc(func(result any, meta *ResultMeta) any {
+			return map[string]any{"result": result, "meta": meta}
+		}),
+	}
+}
+
+// RegisterResultEnvelope registers an envelope which can be
+// selected by clients; existing envelopes will be replaced.
+func (m *MethodHandler) RegisterResultEnvelope(name string, envelope ResultEnvelope) {
+	m.envelopes[name] = envelope
+}
+
+// SetResultEnvelopeSelector sets the function selecting the envelope by name
+// for a request, e.g. based on a client profile. Websocket clients are
+// selected using the request opening the connection.
+// By default, the envelope is selected using the X-Result-Envelope header.
+func (m *MethodHandler) SetResultEnvelopeSelector(fn func(r *http.Request) string) {
+	m.envelopeSelector = fn
+}
+
+func selectResultEnvelope(r *http.Request) string {
+	return r.Header.Get(ResultEnvelopeHeader)
+}
+
+// wrapResult wraps the result using the envelope selected for the request;
+// unknown envelopes return the bare result
+func (m *MethodHandler) wrapResult(r *http.Request, result any, meta *ResultMeta) any {
+	switch result.(type) {
+	case HTML, *BinaryResult:
+		return result
+	}
+	if r == nil {
+		return result
+	}
+	envelope, ok := m.envelopes[m.envelopeSelector(r)]
+	if !ok {
+		return result
+	}
+	return envelope.Wrap(result, meta)
+}
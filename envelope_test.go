@@ -0,0 +1,78 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResultEnvelope(t *testing.T) {
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterSystem(&MethodHandlerTest{})
+	mh.ConfigureMethod("method-handler-test/sleep.v1", Timeout(20*time.Millisecond))
+
+	call := func(t *testing.T, envelope string, method string, params string) map[string]json.RawMessage {
+		t.Helper()
+		body := []byte(`{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":` + params + `}`)
+		req := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(body))
+		if envelope != "" {
+			req.Header.Set(ResultEnvelopeHeader, envelope)
+		}
+		w := httptest.NewRecorder()
+		NewHttpRpcHandler(mh, "/rpc").Handle(w, req)
+		out := map[string]json.RawMessage{}
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		envelope string
+		expected string
+	}{
+		{"bare by default", "", `"Silvio"`},
+		{"bare", ResultEnvelopeBare, `"Silvio"`},
+		{"unknown envelope", "unknown", `"Silvio"`},
+		{"data", ResultEnvelopeData, `{"data":"Silvio"}`},
+		{"meta", ResultEnvelopeMeta, `{"meta":{},"result":"Silvio"}`},
+	}
+	for _, tt := range tests {
+		t.Run("expect "+tt.name+" result", func(t *testing.T) {
+			resp := call(t, tt.envelope, "method-handler-test/echo.v1", `{"name":"Silvio"}`)
+			if string(resp["result"]) != tt.expected {
+				t.Fatalf("expected %s, got: %s", tt.expected, resp["result"])
+			}
+		})
+	}
+
+	t.Run("expect meta envelope to contain warnings", func(t *testing.T) {
+		resp := call(t, ResultEnvelopeMeta, "method-handler-test/sleep.v1", `{"millis":20}`)
+		result := &struct {
+			Meta *ResultMeta `json:"meta"`
+		}{}
+		if err := json.Unmarshal(resp["result"], result); err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Meta.Warnings) != 1 || result.Meta.Warnings[0].Code != WarningTimeoutBudget {
+			t.Fatalf("expected timeout budget warning, got: %s", resp["result"])
+		}
+		if resp["warnings"] == nil {
+			t.Fatal("expected warnings to remain within the response")
+		}
+	})
+
+	t.Run("expect custom envelopes to be selectable", func(t *testing.T) {
+		mh.RegisterResultEnvelope("v1", ResultEnvelopeFunc(func(result any, meta *ResultMeta) any {
+			return []any{result}
+		}))
+		resp := call(t, "v1", "method-handler-test/echo.v1", `{"name":"Silvio"}`)
+		if string(resp["result"]) != `["Silvio"]` {
+			t.Fatalf("expected custom envelope, got: %s", resp["result"])
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package jonson
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -11,17 +12,56 @@ type Error struct {
 	Code    int        `json:"code"`
 	Message string     `json:"message"`
 	Data    *ErrorData `json:"data,omitempty"`
+	// cause contains the original error in case
+	// the error has been created using AsError or Debugf
+	cause error
 }
 
 func (e *Error) Error() string {
 	return e.Message + " (" + strconv.FormatInt(int64(e.Code), 10) + ")"
 }
 
+// Unwrap returns the original error wrapped by AsError
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// AsError converts any error to a jonson Error:
+// Errors are returned as they are, wrapped Errors are unwrapped
+// and all other errors are wrapped in ErrInternal. The original error's
+// message will be encoded into the debug data once the error is returned to the client.
+func AsError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	e = ErrInternal.CloneWithData(nil)
+	e.cause = err
+	return e
+}
+
 // ErrorData object
 type ErrorData struct {
 	Path    []any    `json:"path,omitempty"`
 	Details []*Error `json:"details,omitempty"`
 	Debug   string   `json:"debug,omitempty"`
+	// Chain contains the encoded layers of the wrapped cause
+	// in case error chains have been enabled
+	Chain []string `json:"chain,omitempty"`
+	// Fields maps fields to their validation message;
+	// set by ValidationErrors
+	Fields map[string]string `json:"fields,omitempty"`
+	// Params contains the encoded raw params in case
+	// echoing params on error has been enabled
+	Params string `json:"params,omitempty"`
+	// RequestID is set in case a method panicked
+	RequestID string `json:"requestId,omitempty"`
 }
 
 // indents a block of text with an indent string
@@ -40,6 +80,25 @@ func indent(text, indent string) string {
 	return result[:len(result)-1]
 }
 
+// clone returns a copy which can be modified
+// without affecting the original data
+func (e *ErrorData) clone() *ErrorData {
+	if e == nil {
+		return nil
+	}
+	out := *e
+	out.Path = append([]any(nil), e.Path...)
+	out.Details = append([]*Error(nil), e.Details...)
+	out.Chain = append([]string(nil), e.Chain...)
+	if e.Fields != nil {
+		out.Fields = make(map[string]string, len(e.Fields))
+		for k, v := range e.Fields {
+			out.Fields[k] = v
+		}
+	}
+	return &out
+}
+
 func (e ErrorData) String() string {
 	paths := []string{}
 	for _, v := range e.Path {
@@ -62,6 +121,33 @@ func (e Error) CloneWithData(data *ErrorData) *Error {
 	return &e
 }
 
+// Debugf returns a copy with a formatted debug message:
+//
+//	return nil, ErrInvalidParams.Debugf("unknown account %s", id)
+//
+// The message will be encoded using the method handler's secret
+// once the error is returned to the client. Errors wrapped using %w
+// remain retrievable using errors.Is and errors.As.
+func (e Error) Debugf(format string, args ...any) *Error {
+	e.Data = e.Data.clone()
+	if e.Data != nil {
+		e.Data.Debug = ""
+	}
+	e.cause = fmt.Errorf(format, args...)
+	return &e
+}
+
+// Detailsf returns a copy with the given detail appended to its details;
+// the detail receives a formatted debug message, see Debugf
+func (e Error) Detailsf(detail *Error, format string, args ...any) *Error {
+	e.Data = e.Data.clone()
+	if e.Data == nil {
+		e.Data = &ErrorData{}
+	}
+	e.Data.Details = append(e.Data.Details, detail.Debugf(format, args...))
+	return &e
+}
+
 func (e Error) String() string {
 	data := ""
 	if e.Data != nil {
@@ -0,0 +1,135 @@
+package jonson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// DefaultStreamDetailsThreshold is the default number of error details
+// above which the details will be streamed
+const DefaultStreamDetailsThreshold = 1000
+
+// SetStreamDetailsThreshold sets the number of error details (e.g. per-row errors
+// of a bulk import) above which the details of an http error response
+// are encoded and written one by one instead of encoding the whole response at once;
+// 0 disables streaming.
+func (m *MethodHandler) SetStreamDetailsThreshold(threshold int) {
+	m.streamDetailsThreshold = threshold
+}
+
+// streamedDetailsMarker takes the place of the streamed details
+// while encoding the surrounding response
+var streamedDetailsMarker = &Error{Message: "\x00jonson:streamed-details\x00"}
+
+// writeResponse writes the response encoded using the given codec to w;
+// using json, the details of errors exceeding the stream threshold will be streamed
+func (m *MethodHandler) writeResponse(w io.Writer, codec ResponseCodec, resp any) error {
+	if _, ok := codec.(*JSONCodec); !ok {
+		b, err := codec.Marshal(codecValue(resp))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+
+	switch v := resp.(type) {
+	case *RPCErrorResponse:
+		if m.streamsDetails(v.Error) {
+			return writeStreamedDetails(w, v.Error, func(e *Error) any {
+				out := *v
+				out.Error = e
+				return &out
+			})
+		}
+	case *Error:
+		if m.streamsDetails(v) {
+			return writeStreamedDetails(w, v, func(e *Error) any {
+				return e
+			})
+		}
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// writeBatchResponse writes the batch response; using json,
+// the response will be written entry by entry
+func (m *MethodHandler) writeBatchResponse(w io.Writer, codec ResponseCodec, resp []any) error {
+	if _, ok := codec.(*JSONCodec); !ok {
+		values := make([]any, len(resp))
+		for i := range resp {
+			values[i] = codecValue(resp[i])
+		}
+		b, err := codec.Marshal(values)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+
+	if _, err := w.Write([]byte("[")); err != nil {
+		return err
+	}
+	for i, entry := range resp {
+		if i > 0 {
+			if _, err := w.Write([]byte(",")); err != nil {
+				return err
+			}
+		}
+		if err := m.writeResponse(w, codec, entry); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write([]byte("]"))
+	return err
+}
+
+func (m *MethodHandler) streamsDetails(err *Error) bool {
+	return m.streamDetailsThreshold > 0 && err != nil && err.Data != nil && len(err.Data.Details) > m.streamDetailsThreshold
+}
+
+// writeStreamedDetails encodes the response returned by wrap while
+// encoding err's details one by one; wrap returns the response containing
+// the given error which will be used in place of err
+func writeStreamedDetails(w io.Writer, err *Error, wrap func(e *Error) any) error {
+	data := *err.Data
+	data.Details = []*Error{streamedDetailsMarker}
+	b, e := json.Marshal(wrap(err.CloneWithData(&data)))
+	if e != nil {
+		return e
+	}
+	marker, _ := json.Marshal(streamedDetailsMarker)
+	pos := bytes.Index(b, marker)
+
+	bw := bufio.NewWriter(w)
+	if _, e := bw.Write(b[:pos]); e != nil {
+		return e
+	}
+	for i, detail := range err.Data.Details {
+		if i > 0 {
+			if e := bw.WriteByte(','); e != nil {
+				return e
+			}
+		}
+		d, e := json.Marshal(detail)
+		if e != nil {
+			return e
+		}
+		if _, e := bw.Write(d); e != nil {
+			return e
+		}
+	}
+	if _, e := bw.Write(b[pos+len(marker):]); e != nil {
+		return e
+	}
+	return bw.Flush()
+}
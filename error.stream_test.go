@@ -0,0 +1,106 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// errorStreamTestWriter counts the writes of the response body
+type errorStreamTestWriter struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (w *errorStreamTestWriter) Write(b []byte) (int, error) {
+	w.writes++
+	return w.ResponseRecorder.Write(b)
+}
+
+func TestStreamDetails(t *testing.T) {
+	const rows = 5000
+	details := make([]*Error, rows)
+	for i := range details {
+		details[i] = &Error{Code: 1001, Message: "invalid row", Data: &ErrorData{Path: []any{"rows", i}}}
+	}
+	expected := ErrInvalidParams.CloneWithData(&ErrorData{Debug: "import failed", Details: details})
+
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterMethod(&MethodDefinition{
+		System:  "stream-test",
+		Method:  "import",
+		Version: 1,
+		HandlerFunc: func(ctx *Context) error {
+			return expected
+		},
+	})
+
+	call := func(t *testing.T, handler interface {
+		Handle(w http.ResponseWriter, r *http.Request) bool
+	}, req *http.Request) *errorStreamTestWriter {
+		t.Helper()
+		w := &errorStreamTestWriter{ResponseRecorder: httptest.NewRecorder()}
+		if !handler.Handle(w, req) {
+			t.Fatal("expected request to be handled")
+		}
+		if !json.Valid(w.Body.Bytes()) {
+			t.Fatalf("expected valid json, got: %s", w.Body.String())
+		}
+		return w
+	}
+
+	decode := func(t *testing.T, b []byte) *Error {
+		t.Helper()
+		out := &Error{}
+		if err := json.Unmarshal(b, out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	want, _ := json.Marshal(expected)
+
+	t.Run("expect large details to be streamed", func(t *testing.T) {
+		mh.SetStreamDetailsThreshold(100)
+		w := call(t, NewHttpMethodHandler(mh), httptest.NewRequest(http.MethodGet, "/stream-test/import.v1", nil))
+		if w.writes < 2 {
+			t.Fatalf("expected details to be written incrementally, got %d writes", w.writes)
+		}
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got: %d", w.Code)
+		}
+		if !bytes.Equal(w.Body.Bytes(), want) {
+			t.Fatal("expected streamed error to equal the buffered encoding")
+		}
+	})
+
+	t.Run("expect large details to be streamed within rpc responses", func(t *testing.T) {
+		mh.SetStreamDetailsThreshold(100)
+		body := `[{"jsonrpc":"2.0","id":1,"method":"stream-test/import.v1"},{"jsonrpc":"2.0","id":2,"method":"stream-test/import.v1"}]`
+		w := call(t, NewHttpRpcHandler(mh, "/rpc"), httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader([]byte(body))))
+		out := []*RPCErrorResponse{}
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 2 || string(out[1].ID) != "2" || len(out[1].Error.Data.Details) != rows {
+			t.Fatalf("expected two responses with %d details each", rows)
+		}
+		if last := out[1].Error.Data.Details[rows-1]; !reflect.DeepEqual(last.Data.Path, []any{"rows", float64(rows - 1)}) {
+			t.Fatalf("unexpected last detail: %v", last.Data.Path)
+		}
+	})
+
+	t.Run("expect small details to be written at once", func(t *testing.T) {
+		mh.SetStreamDetailsThreshold(rows)
+		w := call(t, NewHttpMethodHandler(mh), httptest.NewRequest(http.MethodGet, "/stream-test/import.v1", nil))
+		if w.writes != 1 {
+			t.Fatalf("expected a single write, got: %d", w.writes)
+		}
+		if out := decode(t, w.Body.Bytes()); len(out.Data.Details) != rows || out.Data.Debug != "import failed" {
+			t.Fatalf("unexpected error with %d details", len(out.Data.Details))
+		}
+	})
+}
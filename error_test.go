@@ -0,0 +1,127 @@
+package jonson
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestAsError(t *testing.T) {
+	t.Run("expect nil to remain nil", func(t *testing.T) {
+		if err := AsError(nil); err != nil {
+			t.Fatalf("expected nil, got: %v", err)
+		}
+	})
+
+	t.Run("expect Error to be returned unchanged", func(t *testing.T) {
+		if err := AsError(ErrUnauthorized); err != ErrUnauthorized {
+			t.Fatalf("expected ErrUnauthorized, got: %v", err)
+		}
+	})
+
+	t.Run("expect wrapped Error to be unwrapped", func(t *testing.T) {
+		if err := AsError(fmt.Errorf("check failed: %w", ErrUnauthorized)); err != ErrUnauthorized {
+			t.Fatalf("expected ErrUnauthorized, got: %v", err)
+		}
+	})
+
+	t.Run("expect plain error to be wrapped in ErrInternal", func(t *testing.T) {
+		cause := errors.New("connection refused")
+		err := AsError(cause)
+		if err.Code != ErrInternal.Code {
+			t.Fatalf("expected internal error, got: %v", err)
+		}
+		if !errors.Is(err, cause) || errors.Unwrap(err) != cause {
+			t.Fatal("expected original error to be retrievable")
+		}
+		if ErrInternal.Unwrap() != nil {
+			t.Fatal("expected ErrInternal not to be modified")
+		}
+	})
+
+	t.Run("expect cause to be encoded into debug data", func(t *testing.T) {
+		secret := NewDebugSecret()
+		mh := NewMethodHandler(NewFactory(), secret, nil)
+		err := mh.encodeCause(AsError(errors.New("connection refused")))
+		if err.Data == nil || err.Data.Debug != secret.Encode("connection refused") {
+			t.Fatalf("expected encoded cause, got: %v", err.Data)
+		}
+	})
+}
+
+func TestErrorDebugf(t *testing.T) {
+	secret := NewDebugSecret()
+	mh := NewMethodHandler(NewFactory(), secret, nil)
+	prototype := &Error{Code: 1001, Message: "conflict", Data: &ErrorData{Path: []any{"name"}, Debug: "original"}}
+
+	t.Run("expect formatted debug message to be encoded", func(t *testing.T) {
+		err := mh.encodeCause(prototype.Debugf("account %d already exists", 42))
+		if err.Code != 1001 || err.Data.Debug != secret.Encode("account 42 already exists") {
+			t.Fatalf("expected encoded debug message, got: %v", err.Data)
+		}
+		if len(err.Data.Path) != 1 || err.Data.Path[0] != "name" {
+			t.Fatalf("expected data to be retained, got: %v", err.Data)
+		}
+	})
+
+	t.Run("expect wrapped errors to be retrievable", func(t *testing.T) {
+		cause := errors.New("unique constraint")
+		if err := prototype.Debugf("insert failed: %w", cause); !errors.Is(err, cause) {
+			t.Fatal("expected wrapped error to be retrievable")
+		}
+	})
+
+	t.Run("expect details to be appended and encoded", func(t *testing.T) {
+		err := ErrInvalidParams.Detailsf(prototype, "field %s", "name").Detailsf(prototype, "field %s", "email")
+		err = mh.encodeCause(err)
+		if len(err.Data.Details) != 2 || err.Data.Details[1].Data.Debug != secret.Encode("field email") {
+			t.Fatalf("expected two encoded details, got: %v", err.Data)
+		}
+	})
+
+	t.Run("expect prototypes to remain unchanged", func(t *testing.T) {
+		prototype.Debugf("changed")
+		prototype.Detailsf(ErrInternal, "changed")
+		ErrInvalidParams.Debugf("changed")
+		if prototype.Data.Debug != "original" || len(prototype.Data.Details) != 0 || prototype.Unwrap() != nil {
+			t.Fatalf("expected prototype to be unchanged, got: %v", prototype.Data)
+		}
+		if ErrInvalidParams.Data != nil || ErrInvalidParams.Unwrap() != nil {
+			t.Fatal("expected ErrInvalidParams to be unchanged")
+		}
+	})
+}
+
+type errorTestSecret struct{}
+
+func (errorTestSecret) Encode(in string) string {
+	return "<" + in + ">"
+}
+
+func (errorTestSecret) Decode(in string) (string, error) {
+	return in[1 : len(in)-1], nil
+}
+
+func TestErrorChain(t *testing.T) {
+	cause := fmt.Errorf("load account: %w", fmt.Errorf("query accounts: %w", errors.New("connection refused")))
+
+	t.Run("expect all layers to be encoded", func(t *testing.T) {
+		mh := NewMethodHandler(NewFactory(), errorTestSecret{}, nil)
+		mh.SetErrorChain(true)
+		err := mh.encodeCause(AsError(cause))
+		expected := []string{"<load account>", "<query accounts>", "<connection refused>"}
+		if fmt.Sprint(err.Data.Chain) != fmt.Sprint(expected) {
+			t.Fatalf("expected chain %v, got: %v", expected, err.Data.Chain)
+		}
+		if err.Data.Debug != "<"+cause.Error()+">" {
+			t.Fatalf("expected debug to contain the whole message, got: %s", err.Data.Debug)
+		}
+	})
+
+	t.Run("expect no chain by default", func(t *testing.T) {
+		mh := NewMethodHandler(NewFactory(), errorTestSecret{}, nil)
+		if err := mh.encodeCause(AsError(cause)); err.Data.Chain != nil {
+			t.Fatalf("expected no chain, got: %v", err.Data.Chain)
+		}
+	})
+}
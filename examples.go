@@ -0,0 +1,110 @@
+package jonson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// MethodExample is an example call of a method
+type MethodExample struct {
+	Name   string
+	Params any
+	Result any
+}
+
+// ExampleCall adds an example call to the method; examples are listed
+// within the OpenRPC document and can be verified using VerifyExamples.
+// Params and result need to be encodable using the json codec.
+func ExampleCall(name string, params any, result any) MethodOption {
+	return func(def *MethodDefinition) {
+		def.Examples = append(def.Examples, &MethodExample{
+			Name:   name,
+			Params: params,
+			Result: result,
+		})
+	}
+}
+
+// exampleValue returns the value as seen by clients
+func (m *MethodHandler) exampleValue(v any) (any, error) {
+	b, err := m.codecs[ContentTypeJSON].Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VerifyExamples calls the methods using the params of their examples
+// and compares the results with the examples' results, e.g. as smoke test
+// catching drift between documentation and implementation.
+// Examples are called like rpc calls of a client without any request headers.
+// All failing examples are reported within the returned error.
+func (m *MethodHandler) VerifyExamples() error {
+	names := make([]string, 0, len(m.endpoints))
+	for name := range m.endpoints {
+		if _, ok := m.localEndpoint(name); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		for _, example := range m.endpoints[name].def.Examples {
+			if err := m.verifyExample(name, example); err != nil {
+				errs = append(errs, fmt.Errorf("example %s of %s: %w", example.Name, name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *MethodHandler) verifyExample(method string, example *MethodExample) error {
+	params, err := m.codecs[ContentTypeJSON].Marshal(example.Params)
+	if err != nil {
+		return err
+	}
+	expected, err := m.exampleValue(example.Result)
+	if err != nil {
+		return err
+	}
+
+	r, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "/", nil)
+	if err != nil {
+		return err
+	}
+	resp := m.processMessage(r, nil, nil, &RPCRequest{
+		Version: "2.0",
+		ID:      json.RawMessage("1"),
+		Method:  method,
+		Params:  params,
+	}, nil)
+
+	switch v := resp.(type) {
+	case *RPCErrorResponse:
+		return v.Error
+	case *RPCResultResponse:
+		actual, err := m.exampleValue(v.Result)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			a, _ := json.Marshal(actual)
+			e, _ := json.Marshal(expected)
+			return fmt.Errorf("expected result %s, got: %s", e, a)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package jonson
+
+import (
+	"strings"
+	"testing"
+)
+
+type examplesTestParams struct {
+	Params
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+type examplesTestResult struct {
+	Sum int `json:"sum"`
+}
+
+func TestVerifyExamples(t *testing.T) {
+	register := func(examples ...MethodOption) *MethodHandler {
+		mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+		mh.RegisterMethod(&MethodDefinition{
+			System:  "examples-test",
+			Method:  "sum",
+			Version: 1,
+			HandlerFunc: func(ctx *Context, params *examplesTestParams) (*examplesTestResult, error) {
+				if params.A < 0 {
+					return nil, ErrInvalidParams
+				}
+				return &examplesTestResult{Sum: params.A + params.B}, nil
+			},
+		}, examples...)
+		return mh
+	}
+
+	t.Run("expect matching examples to pass", func(t *testing.T) {
+		mh := register(
+			ExampleCall("small", &examplesTestParams{A: 1, B: 2}, &examplesTestResult{Sum: 3}),
+			ExampleCall("map", map[string]any{"a": 40, "b": 2}, map[string]any{"sum": 42}),
+		)
+		if err := mh.VerifyExamples(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("expect drifting examples to be reported", func(t *testing.T) {
+		mh := register(
+			ExampleCall("small", &examplesTestParams{A: 1, B: 2}, &examplesTestResult{Sum: 4}),
+			ExampleCall("negative", &examplesTestParams{A: -1}, &examplesTestResult{}),
+		)
+		err := mh.VerifyExamples()
+		if err == nil {
+			t.Fatal("expected examples to fail")
+		}
+		for _, v := range []string{"example small of examples-test/sum.v1", `expected result {"sum":4}, got: {"sum":3}`, "example negative", "Invalid params"} {
+			if !strings.Contains(err.Error(), v) {
+				t.Fatalf("expected error to contain %q, got: %s", v, err)
+			}
+		}
+	})
+}
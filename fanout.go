@@ -0,0 +1,91 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// FanOut runs each fn concurrently within its own forked context whose
+// deadline is set to timeout. The results of all branches returning successfully
+// before the deadline are returned in order; branches failing or exceeding the deadline
+// are returned as errors (the error's path contains the branch's index) so the caller
+// can return partial results.
+// Each forked context is finalized before FanOut returns;
+// FanOut waits for all fns to return, fns must therefore respect the context's cancellation.
+func FanOut[T any](ctx *Context, timeout time.Duration, fns ...func(*Context) (T, error)) ([]T, []*Error) {
+	parent, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		val T
+		err *Error
+	}
+
+	var (
+		results = make([]result, len(fns))
+		wg      sync.WaitGroup
+	)
+
+	for i, fn := range fns {
+		wg.Add(1)
+		go func(i int, fn func(*Context) (T, error)) {
+			defer wg.Done()
+
+			child := ctx.forkWithParent(parent)
+			val, err := func() (val T, err error) {
+				defer func() {
+					if r := recover(); r != nil {
+						err = getRecoverError(r)
+					}
+				}()
+				return fn(child)
+			}()
+			late := parent.Err() != nil
+			err = child.Finalize(err)
+
+			switch {
+			case late && (err == nil || errors.Is(err, context.DeadlineExceeded)):
+				results[i].err = ErrTimeout.CloneWithData(&ErrorData{
+					Path: []any{i},
+				})
+			case err != nil:
+				results[i].err = ctx.fanOutError(i, err)
+			default:
+				results[i].val = val
+			}
+		}(i, fn)
+	}
+	wg.Wait()
+
+	var (
+		vals []T
+		errs []*Error
+	)
+	for _, v := range results {
+		if v.err != nil {
+			errs = append(errs, v.err)
+			continue
+		}
+		vals = append(vals, v.val)
+	}
+	return vals, errs
+}
+
+func (c *Context) fanOutError(branch int, err error) *Error {
+	log.Printf("fan out: branch %d failed: %s", branch, err)
+	if e, ok := err.(*Error); ok {
+		data := ErrorData{}
+		if e.Data != nil {
+			data = *e.Data
+		}
+		data.Path = append([]any{branch}, data.Path...)
+		return e.CloneWithData(&data)
+	}
+	return ErrInternal.CloneWithData(&ErrorData{
+		Path:  []any{branch},
+		Debug: c.methodHandler.errorEncoder.Encode(err.Error()),
+	})
+}
@@ -0,0 +1,82 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var typeFanOutTestResource = reflect.TypeOf((**fanOutTestResource)(nil)).Elem()
+
+type fanOutTestResource struct {
+	finalized bool
+}
+
+func (f *fanOutTestResource) Finalize(errs []error) error {
+	f.finalized = true
+	return nil
+}
+
+func TestFanOut(t *testing.T) {
+	fac := NewFactory()
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	succeed := func(v int) func(*Context) (int, error) {
+		return func(ctx *Context) (int, error) {
+			return v, nil
+		}
+	}
+
+	t.Run("expect all results on success", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		vals, errs := FanOut(ctx, time.Second, succeed(1), succeed(2), succeed(3))
+		if len(errs) != 0 {
+			t.Fatalf("expected no errors, got: %v", errs)
+		}
+		if len(vals) != 3 || vals[0] != 1 || vals[1] != 2 || vals[2] != 3 {
+			t.Fatalf("expected results in order, got: %v", vals)
+		}
+	})
+
+	t.Run("expect timed out branches to be reported", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		resource := &fanOutTestResource{}
+		slow := func(ctx *Context) (int, error) {
+			ctx.StoreValue(typeFanOutTestResource, resource)
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		vals, errs := FanOut(ctx, 20*time.Millisecond, succeed(1), slow)
+		if len(vals) != 1 || vals[0] != 1 {
+			t.Fatalf("expected partial result, got: %v", vals)
+		}
+		if len(errs) != 1 || errs[0].Code != ErrTimeout.Code || errs[0].Data.Path[0] != 1 {
+			t.Fatalf("expected timeout of branch 1, got: %v", errs)
+		}
+		if !resource.finalized {
+			t.Fatal("expected timed out branch to be finalized")
+		}
+	})
+
+	t.Run("expect failed branches to be reported", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		fail := func(ctx *Context) (int, error) {
+			return 0, errors.New("backend unavailable")
+		}
+		unauthorized := func(ctx *Context) (int, error) {
+			return 0, ErrUnauthorized
+		}
+		vals, errs := FanOut(ctx, time.Second, fail, succeed(2), unauthorized)
+		if len(vals) != 1 || vals[0] != 2 {
+			t.Fatalf("expected partial result, got: %v", vals)
+		}
+		if len(errs) != 2 || errs[0].Code != ErrInternal.Code || errs[1].Code != ErrUnauthorized.Code {
+			t.Fatalf("expected internal and unauthorized errors, got: %v", errs)
+		}
+		if ErrUnauthorized.Data != nil {
+			t.Fatal("expected prototype error not to be modified")
+		}
+	})
+}
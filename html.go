@@ -0,0 +1,78 @@
+package jonson
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"io"
+	"log"
+	"reflect"
+)
+
+// ContentTypeHTML is the content type of rendered html results
+const ContentTypeHTML = "text/html"
+
+// HTMLResult may be returned by methods which render html;
+// the template will be executed using the method handler's TemplateRenderer.
+// Http methods respond with the rendered html instead of json,
+// rpc responses contain the rendered html as string.
+type HTMLResult struct {
+	Template string
+	Data     any
+}
+
+// HTML is the rendered html of an HTMLResult
+type HTML string
+
+var typeHTMLResult = reflect.TypeOf(HTMLResult{})
+
+// TemplateRenderer executes templates
+type TemplateRenderer interface {
+	Render(w io.Writer, name string, data any) error
+}
+
+type htmlTemplateRenderer struct {
+	templates *template.Template
+}
+
+// NewHTMLTemplateRenderer returns a renderer executing
+// the named templates of the given html templates
+func NewHTMLTemplateRenderer(templates *template.Template) TemplateRenderer {
+	return &htmlTemplateRenderer{
+		templates: templates,
+	}
+}
+
+func (h *htmlTemplateRenderer) Render(w io.Writer, name string, data any) error {
+	return h.templates.ExecuteTemplate(w, name, data)
+}
+
+// SetTemplateRenderer sets the renderer used to render
+// HTMLResults returned by methods
+func (m *MethodHandler) SetTemplateRenderer(renderer TemplateRenderer) {
+	m.templateRenderer = renderer
+}
+
+// render renders the result in case it is an HTMLResult
+func (m *MethodHandler) render(res any) (any, error) {
+	result, ok := res.(*HTMLResult)
+	if !ok {
+		return res, nil
+	}
+	if result == nil {
+		return nil, nil
+	}
+
+	err := errors.New("no template renderer set")
+	buf := &bytes.Buffer{}
+	if m.templateRenderer != nil {
+		err = m.templateRenderer.Render(buf, result.Template, result.Data)
+	}
+	if err != nil {
+		log.Print("method handler: render error: ", err)
+		return nil, ErrInternal.CloneWithData(&ErrorData{
+			Debug: m.errorEncoder.Encode(err.Error()),
+		})
+	}
+	return HTML(buf.String()), nil
+}
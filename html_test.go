@@ -0,0 +1,70 @@
+package jonson
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type HTMLTest struct{}
+
+type htmlTestPage struct {
+	Name string
+}
+
+func (h *HTMLTest) PageV1(ctx *Context) (*HTMLResult, error) {
+	return &HTMLResult{Template: "page", Data: &htmlTestPage{Name: "<Silvio>"}}, nil
+}
+
+func (h *HTMLTest) BrokenV1(ctx *Context) (*HTMLResult, error) {
+	return &HTMLResult{Template: "broken", Data: &htmlTestPage{}}, nil
+}
+
+func TestHTMLResult(t *testing.T) {
+	templates := template.Must(template.New("page").Parse(`<h1>{{.Name}}</h1>`))
+	template.Must(templates.New("broken").Parse(`<h1>{{.Missing}}</h1>`))
+
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterSystem(&HTMLTest{})
+	mh.SetTemplateRenderer(NewHTMLTemplateRenderer(templates))
+	handler := NewHttpMethodHandler(mh)
+
+	t.Run("expect template to be rendered as html", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Handle(w, httptest.NewRequest(http.MethodGet, "/html-test/page.v1", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got: %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, ContentTypeHTML) {
+			t.Fatalf("expected html content type, got: %s", ct)
+		}
+		if body := w.Body.String(); body != "<h1>&lt;Silvio&gt;</h1>" {
+			t.Fatalf("expected rendered html, got: %s", body)
+		}
+	})
+
+	t.Run("expect template errors to become internal errors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Handle(w, httptest.NewRequest(http.MethodGet, "/html-test/broken.v1", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status 500, got: %d", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), `"code":-32603`) {
+			t.Fatalf("expected internal error, got: %s", w.Body.String())
+		}
+	})
+
+	t.Run("expect rpc responses to contain html as string", func(t *testing.T) {
+		resp := callRPC(t, mh, "html-test/page.v1", nil)
+		var html string
+		if err := json.Unmarshal(resp["result"], &html); err != nil {
+			t.Fatal(err)
+		}
+		if html != "<h1>&lt;Silvio&gt;</h1>" {
+			t.Fatalf("expected html string, got: %s", html)
+		}
+	})
+}
@@ -75,6 +75,7 @@ func (h *HttpRegexpHandler) RegisterRegexp(pattern *regexp.Regexp, handler func(
 		ctx.StoreValue(TypeHTTPRequest, r)
 		ctx.StoreValue(TypeHTTPResponseWriter, w)
 		ctx.StoreValue(TypeSecret, h.methodHandler.errorEncoder)
+		ctx.StoreValue(TypeQueryCounter, NewQueryCounter(r.URL.Path, h.methodHandler.queryWarnThreshold))
 		defer ctx.Finalize(nil)
 
 		handler(ctx, w, r, parts)
@@ -113,12 +114,18 @@ func (h *HttpRpcHandler) Handle(w http.ResponseWriter, req *http.Request) bool {
 		batch bool
 	)
 
+	tracked := trackBody(req)
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
 		log.Print("rpc http handler: read error: ", err)
-		resp = []any{NewRPCErrorResponse(nil, ErrParse)}
+		resp = []any{NewRPCErrorResponse(nil, readError(tracked))}
 	} else {
-		resp, batch = h.methodHandler.processMessages(req, w, nil, body)
+		tracker := newHijackTracker(w)
+		resp, batch = h.methodHandler.processMessages(req, tracker, nil, body)
+		if tracker.hijacked {
+			// the connection is owned by the method
+			return true
+		}
 	}
 
 	if len(resp) == 0 {
@@ -127,19 +134,21 @@ func (h *HttpRpcHandler) Handle(w http.ResponseWriter, req *http.Request) bool {
 		return true
 	}
 
+	codec := h.methodHandler.responseCodec(req)
+	setResponseContentType(w, codec)
+
 	// no batch response
 	if !batch {
 		// single response
-		b, _ := json.Marshal(resp[0])
+		writeServerTiming(w, resp[0])
 		w.WriteHeader(http.StatusOK)
-		w.Write(b)
+		h.methodHandler.writeResponse(w, codec, resp[0])
 		return true
 	}
 
 	// batch response
-	b, _ := json.Marshal(resp)
 	w.WriteHeader(http.StatusOK)
-	w.Write(b)
+	h.methodHandler.writeBatchResponse(w, codec, resp)
 	return true
 }
 
@@ -169,7 +178,7 @@ func (h *HttpMethodHandler) Handle(w http.ResponseWriter, req *http.Request) boo
 		// trim leading slash
 		p = p[1:]
 	}
-	endpoint, ok := h.methodHandler.endpoints[p]
+	endpoint, ok := h.methodHandler.lookupEndpoint(p)
 	if !ok {
 		return false
 	}
@@ -178,7 +187,9 @@ func (h *HttpMethodHandler) Handle(w http.ResponseWriter, req *http.Request) boo
 		// no params available, we accept only GET
 		acceptedHttpMethod = "GET"
 	}
-	if acceptedHttpMethod != req.Method {
+	// methods accepting query params can be called using GET
+	query := endpoint.paramsPos >= 0 && endpoint.def.QueryParams && req.Method == "GET"
+	if acceptedHttpMethod != req.Method && !query {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		b, _ := json.Marshal(ErrServerMethodNotAllowed)
 		w.Write(b)
@@ -192,27 +203,61 @@ func (h *HttpMethodHandler) Handle(w http.ResponseWriter, req *http.Request) boo
 	// we need to unmarshal the body _only_ in case
 	// parameters are expected; Otherwise the body
 	// can/will be empty
-	if endpoint.paramsPos >= 0 {
-		err = json.NewDecoder(req.Body).Decode(&pl)
+	tracked := trackBody(req)
+	contentType := requestContentType(req)
+	if query {
+		contentType = ContentTypeQuery
+		pl = json.RawMessage(req.URL.RawQuery)
+	} else if endpoint.paramsPos >= 0 {
+		if contentType == "" || contentType == ContentTypeJSON {
+			err = json.NewDecoder(req.Body).Decode(&pl)
+		} else {
+			// non-json payloads will be passed as-is to the
+			// decoder registered for the content type
+			pl, err = io.ReadAll(req.Body)
+		}
 	}
 
 	if err != nil {
 		log.Print("rpc http handler: read error: ", err)
-		resp = NewRPCErrorResponse(nil, ErrParse)
+		resp = NewRPCErrorResponse(nil, readError(tracked))
 	} else {
-		resp = h.methodHandler.processMessage(req, w, nil, &RPCRequest{
+		tracker := newHijackTracker(w)
+		resp = h.methodHandler.processMessage(req, tracker, nil, &RPCRequest{
 			Version: "2.0",
 			Method:  p,
 			// we do not have any IDs here -> set to -1
-			ID:     []byte("-1"),
-			Params: pl,
+			ID:          []byte("-1"),
+			Params:      pl,
+			contentType: contentType,
+			binary:      true,
 		}, nil)
+		if tracker.hijacked {
+			// the connection is owned by the method
+			return true
+		}
 	}
 
+	writeServerTiming(w, resp)
 	successResp, ok := resp.(*RPCResultResponse)
 	httpStatus := http.StatusOK
 	var dataToMarshal = resp
 	if ok {
+		if cc := successResp.cacheControl; cc != nil {
+			if v := cc.String(); v != "" {
+				w.Header().Set(CacheControlHeader, v)
+			}
+		}
+		if html, ok := successResp.Result.(HTML); ok {
+			w.Header().Set("Content-Type", ContentTypeHTML+"; charset=utf-8")
+			w.WriteHeader(httpStatus)
+			w.Write([]byte(html))
+			return true
+		}
+		if bin, ok := successResp.Result.(*BinaryResult); ok && bin != nil {
+			writeBinaryResult(w, bin)
+			return true
+		}
 		dataToMarshal = successResp.Result
 	}
 	errorResp, ok := resp.(*RPCErrorResponse)
@@ -221,6 +266,8 @@ func (h *HttpMethodHandler) Handle(w http.ResponseWriter, req *http.Request) boo
 		switch errorResp.Error.Code {
 		case ErrInvalidParams.Code:
 			fallthrough
+		case ErrRequestIncomplete.Code:
+			fallthrough
 		case ErrParse.Code:
 			httpStatus = http.StatusBadRequest
 		case ErrUnauthorized.Code:
@@ -229,15 +276,27 @@ func (h *HttpMethodHandler) Handle(w http.ResponseWriter, req *http.Request) boo
 			httpStatus = http.StatusForbidden
 		case ErrMethodNotFound.Code:
 			httpStatus = http.StatusNotFound
+		case ErrUnsupportedContentType.Code:
+			httpStatus = http.StatusUnsupportedMediaType
 		default:
 			httpStatus = http.StatusInternalServerError
 		}
 	}
 
 	// single response for these calls allowed only
-	b, _ := json.Marshal(dataToMarshal)
+	codec := h.methodHandler.responseCodec(req)
+	setResponseContentType(w, codec)
 	w.WriteHeader(httpStatus)
-	w.Write(b)
+	h.methodHandler.writeResponse(w, codec, dataToMarshal)
 	return true
 
 }
+
+// readError returns the error of a failed body read: incomplete bodies
+// are reported separately so disconnects can be told apart from malformed input
+func readError(body *bodyTracker) *Error {
+	if body.incomplete {
+		return ErrRequestIncomplete
+	}
+	return ErrParse
+}
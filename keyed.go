@@ -0,0 +1,175 @@
+package jonson
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// RequireKeyed returns the value of type T stored under the given key;
+// in case the context does not hold such a value yet, provide will be called
+// and its result will be stored within the context. Keyed values are finalized
+// like all other values, which allows us to e.g. cache one value per downstream host.
+func RequireKeyed[T any](ctx *Context, key string, provide func(ctx *Context) T) T {
+	v, stored := ctx.reserveKeyed(TypeOf[T](), key)
+	if v == nil {
+		return stored.(T)
+	}
+
+	val := func() T {
+		defer func() {
+			if r := recover(); r != nil {
+				ctx.abandon(v)
+				panic(r)
+			}
+		}()
+		return provide(ctx.provisioningView(v))
+	}()
+	ctx.complete(v, val)
+	return val
+}
+
+// reserveKeyed returns the stored keyed value; in case there is none, a placeholder
+// for the value which is about to be provisioned will be returned.
+// Values being provisioned concurrently are waited for, panics on recursion loops.
+func (c *Context) reserveKeyed(rt reflect.Type, key string) (*valueItem, any) {
+	if err := c.checkFinalized("require " + rt.String()); err != nil {
+		panic(err)
+	}
+	c.mu.Lock()
+	item, err := c.awaitUnlocked(rt, func(v *valueItem) bool {
+		return v.keyed && v.rt == rt && v.key == key
+	})
+	if err != nil {
+		panic(err)
+	}
+	if item != nil {
+		val := item.val
+		c.mu.Unlock()
+		return nil, val
+	}
+
+	c.provisioned++
+	if c.provisioned > c.provisionLimit {
+		c.mu.Unlock()
+		panic(c.provisionLimitError(rt))
+	}
+	v := &valueItem{
+		rt:    rt,
+		keyed: true,
+		key:   key,
+		done:  make(chan struct{}),
+	}
+	c.values = append(c.values, v)
+	c.mu.Unlock()
+	return v, nil
+}
+
+// KeyedPool hands out connections by key, e.g. by downstream host
+type KeyedPool[T any] interface {
+	// Checkout returns a connection for the given key
+	Checkout(key string) (T, error)
+	// Return returns the connection to the pool
+	Return(key string, conn T)
+}
+
+// pooled wraps a checked out connection and returns it
+// to its pool on finalize
+type pooled[T any] struct {
+	pool KeyedPool[T]
+	key  string
+	conn T
+}
+
+func (p *pooled[T]) Finalize(errs []error) error {
+	p.pool.Return(p.key, p.conn)
+	return nil
+}
+
+// RequirePooled checks out a connection for the given key from the pool.
+// The connection is checked out once per context: all subsequent calls within
+// the same request return the same connection. The connection will be returned
+// to the pool once the context finalizes.
+func RequirePooled[T any](ctx *Context, pool KeyedPool[T], key string) (T, error) {
+	v, stored := ctx.reserveKeyed(TypeOf[*pooled[T]](), key)
+	if v == nil {
+		return stored.(*pooled[T]).conn, nil
+	}
+
+	conn, err := func() (T, error) {
+		defer func() {
+			if r := recover(); r != nil {
+				ctx.abandon(v)
+				panic(r)
+			}
+		}()
+		return pool.Checkout(key)
+	}()
+	if err != nil {
+		// abandon placeholder so the next call retries
+		ctx.abandon(v)
+		var zero T
+		return zero, fmt.Errorf("pool: checkout of %s failed: %w", key, err)
+	}
+	ctx.complete(v, &pooled[T]{
+		pool: pool,
+		key:  key,
+		conn: conn,
+	})
+	return conn, nil
+}
+
+func (c *Context) removeValueUnlocked(item *valueItem) {
+	for i, v := range c.values {
+		if v == item {
+			c.values = append(c.values[:i], c.values[i+1:]...)
+			return
+		}
+	}
+}
+
+// SimpleKeyedPool is a basic KeyedPool keeping up to maxIdle
+// idle connections per key
+type SimpleKeyedPool[T any] struct {
+	dial    func(key string) (T, error)
+	maxIdle int
+	mu      sync.Mutex
+	idle    map[string][]T
+}
+
+var _ KeyedPool[any] = (*SimpleKeyedPool[any])(nil)
+
+// NewSimpleKeyedPool returns a pool using dial to open new connections
+func NewSimpleKeyedPool[T any](dial func(key string) (T, error), maxIdle int) *SimpleKeyedPool[T] {
+	return &SimpleKeyedPool[T]{
+		dial:    dial,
+		maxIdle: maxIdle,
+		idle:    map[string][]T{},
+	}
+}
+
+func (p *SimpleKeyedPool[T]) Checkout(key string) (T, error) {
+	p.mu.Lock()
+	if idle := p.idle[key]; len(idle) > 0 {
+		conn := idle[len(idle)-1]
+		p.idle[key] = idle[:len(idle)-1]
+		p.mu.Unlock()
+		return conn, nil
+	}
+	p.mu.Unlock()
+	return p.dial(key)
+}
+
+func (p *SimpleKeyedPool[T]) Return(key string, conn T) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.idle[key]) >= p.maxIdle {
+		// drop connections exceeding the idle limit;
+		// close them in case they implement io.Closer
+		if c, ok := any(conn).(interface{ Close() error }); ok {
+			c.Close()
+		}
+		return
+	}
+	p.idle[key] = append(p.idle[key], conn)
+}
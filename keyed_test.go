@@ -0,0 +1,146 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type keyedTestConn struct {
+	host string
+}
+
+type KeyedTest struct {
+	pool KeyedPool[*keyedTestConn]
+}
+
+func (k *KeyedTest) call(ctx *Context, host string) *keyedTestConn {
+	conn, err := RequirePooled(ctx, k.pool, host)
+	if err != nil {
+		panic(err)
+	}
+	return conn
+}
+
+func TestKeyed(t *testing.T) {
+	fac := NewFactory()
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	t.Run("expect keyed values to be stored per key", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		calls := 0
+		provide := func(ctx *Context) *keyedTestConn {
+			calls++
+			return &keyedTestConn{}
+		}
+		a := RequireKeyed(ctx, "a", provide)
+		b := RequireKeyed(ctx, "b", provide)
+		if a == b || RequireKeyed(ctx, "a", provide) != a || calls != 2 {
+			t.Fatalf("expected one value per key, got %d calls", calls)
+		}
+	})
+
+	t.Run("expect same connection within a request", func(t *testing.T) {
+		dials := 0
+		pool := NewSimpleKeyedPool(func(host string) (*keyedTestConn, error) {
+			dials++
+			return &keyedTestConn{host: host}, nil
+		}, 2)
+		sys := &KeyedTest{pool: pool}
+
+		ctx := NewContext(context.Background(), fac, mh)
+		first := sys.call(ctx, "api.example.com")
+		second := sys.call(ctx, "api.example.com")
+		other := sys.call(ctx, "db.example.com")
+		if first != second {
+			t.Fatal("expected the same connection for the same host")
+		}
+		if first == other || dials != 2 {
+			t.Fatalf("expected one connection per host, got %d dials", dials)
+		}
+		ctx.Finalize(nil)
+
+		// the connection has been returned to the pool
+		// and will be reused by the next request
+		ctx = NewContext(context.Background(), fac, mh)
+		if sys.call(ctx, "api.example.com") != first || dials != 2 {
+			t.Fatal("expected connection to be reused after finalize")
+		}
+		ctx.Finalize(nil)
+	})
+
+	t.Run("expect concurrent callers to retry after a failed checkout", func(t *testing.T) {
+		var (
+			mu       sync.Mutex
+			dials    int
+			started  = make(chan struct{})
+			release  = make(chan struct{})
+			failures = 0
+		)
+		pool := NewSimpleKeyedPool(func(host string) (*keyedTestConn, error) {
+			mu.Lock()
+			dials++
+			first := dials == 1
+			mu.Unlock()
+			if first {
+				close(started)
+				<-release
+				failures++
+				return nil, errors.New("refused")
+			}
+			return &keyedTestConn{host: host}, nil
+		}, 2)
+
+		ctx := NewContext(context.Background(), fac, mh)
+		errs := make(chan error, 2)
+		go func() {
+			_, err := RequirePooled(ctx, pool, "api.example.com")
+			errs <- err
+		}()
+		<-started
+		go func() {
+			_, err := RequirePooled(ctx, pool, "api.example.com")
+			errs <- err
+		}()
+		// give the second caller the chance to wait for the placeholder
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+
+		results := []error{}
+		for i := 0; i < 2; i++ {
+			select {
+			case err := <-errs:
+				results = append(results, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected waiting caller to be released")
+			}
+		}
+		if (results[0] == nil) == (results[1] == nil) || failures != 1 {
+			t.Fatalf("expected a single failed checkout, got: %v", results)
+		}
+		ctx.Finalize(nil)
+	})
+
+	t.Run("expect panicking checkout to leave no placeholder", func(t *testing.T) {
+		panics := true
+		pool := NewSimpleKeyedPool(func(host string) (*keyedTestConn, error) {
+			if panics {
+				panics = false
+				panic("dial")
+			}
+			return &keyedTestConn{host: host}, nil
+		}, 2)
+
+		ctx := NewContext(context.Background(), fac, mh)
+		func() {
+			defer func() { recover() }()
+			RequirePooled(ctx, pool, "api.example.com")
+		}()
+		if conn, err := RequirePooled(ctx, pool, "api.example.com"); err != nil || conn == nil {
+			t.Fatalf("expected checkout to be retried, got: %v", err)
+		}
+		ctx.Finalize(nil)
+	})
+}
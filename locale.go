@@ -0,0 +1,218 @@
+package jonson
+
+import (
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLocale is used in case the client did not ask
+// for a locale we have formatting rules for
+const DefaultLocale = "en-US"
+
+// localeFormat defines how values are formatted within a locale
+type localeFormat struct {
+	Decimal string
+	Group   string
+	// CurrencySuffix places the currency after the amount
+	CurrencySuffix bool
+	// DateLayout is the time layout of dates
+	DateLayout string
+}
+
+var localeRules = map[string]*localeFormat{
+	"en-US": {Decimal: ".", Group: ",", DateLayout: "01/02/2006"},
+	"en-GB": {Decimal: ".", Group: ",", DateLayout: "02/01/2006"},
+	"de-DE": {Decimal: ",", Group: ".", CurrencySuffix: true, DateLayout: "02.01.2006"},
+	"de-CH": {Decimal: ".", Group: "’", DateLayout: "02.01.2006"},
+	"fr-FR": {Decimal: ",", Group: "\u202f", CurrencySuffix: true, DateLayout: "02/01/2006"},
+	"it-IT": {Decimal: ",", Group: ".", CurrencySuffix: true, DateLayout: "02/01/2006"},
+}
+
+// localeLanguages maps languages to their default locale
+var localeLanguages = map[string]string{
+	"en": "en-US",
+	"de": "de-DE",
+	"fr": "fr-FR",
+	"it": "it-IT",
+}
+
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+}
+
+// resolveLocale returns the locale we have rules for; false is returned
+// in case neither the locale nor its language is known
+func resolveLocale(tag string) (string, bool) {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	lang, region, _ := strings.Cut(tag, "-")
+	lang = strings.ToLower(lang)
+	if region != "" {
+		tag = lang + "-" + strings.ToUpper(region)
+		if _, ok := localeRules[tag]; ok {
+			return tag, true
+		}
+	}
+	tag, ok := localeLanguages[lang]
+	return tag, ok
+}
+
+// Locale is the locale of the client
+type Locale struct {
+	// Tag is the BCP 47 tag of a locale jonson has formatting rules for, e.g. de-CH
+	Tag string
+}
+
+var TypeLocale = reflect.TypeOf((**Locale)(nil)).Elem()
+
+// RequireLocale returns the locale of the ongoing request
+func RequireLocale(ctx *Context) *Locale {
+	if v := ctx.Require(TypeLocale); v != nil {
+		return v.(*Locale)
+	}
+	return nil
+}
+
+// NewLocale returns the given locale; DefaultLocale will
+// be used in case there are no formatting rules for the locale
+func NewLocale(tag string) *Locale {
+	if tag, ok := resolveLocale(tag); ok {
+		return &Locale{Tag: tag}
+	}
+	return &Locale{Tag: DefaultLocale}
+}
+
+// ParseAcceptLanguage returns the preferred locale
+// of the given Accept-Language header
+func ParseAcceptLanguage(header string) *Locale {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	candidates := []candidate{}
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		candidates = append(candidates, candidate{tag: tag, q: q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	for _, c := range candidates {
+		if tag, ok := resolveLocale(c.tag); ok && c.q > 0 {
+			return &Locale{Tag: tag}
+		}
+	}
+	return &Locale{Tag: DefaultLocale}
+}
+
+// ProvideLocale provides the locale using the request's Accept-Language header;
+// register the function as provider to use it:
+//
+//	fac.RegisterProviderFunc(jonson.ProvideLocale)
+func ProvideLocale(ctx *Context) *Locale {
+	v, _ := ctx.lookup(TypeHTTPRequest)
+	r, ok := v.(*http.Request)
+	if !ok || r == nil {
+		return NewLocale(DefaultLocale)
+	}
+	return ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+}
+
+// Formatter formats display values according to a locale;
+// use it instead of hard coding formats within methods.
+// Provide the formatter using a provider, e.g.:
+//
+//	fac.RegisterProviderFunc(jonson.ProvideLocale)
+//	fac.RegisterProviderFunc(jonson.ProvideFormatter)
+type Formatter struct {
+	locale *Locale
+	rules  *localeFormat
+}
+
+var TypeFormatter = reflect.TypeOf((**Formatter)(nil)).Elem()
+
+// RequireFormatter returns the formatter of the ongoing request
+func RequireFormatter(ctx *Context) *Formatter {
+	if v := ctx.Require(TypeFormatter); v != nil {
+		return v.(*Formatter)
+	}
+	return nil
+}
+
+// ProvideFormatter provides a formatter using the request's locale
+func ProvideFormatter(ctx *Context) *Formatter {
+	return NewFormatter(RequireLocale(ctx))
+}
+
+func NewFormatter(locale *Locale) *Formatter {
+	rules, ok := localeRules[locale.Tag]
+	if !ok {
+		locale = NewLocale(locale.Tag)
+		rules = localeRules[locale.Tag]
+	}
+	return &Formatter{
+		locale: locale,
+		rules:  rules,
+	}
+}
+
+// Locale returns the locale the formatter uses
+func (f *Formatter) Locale() *Locale {
+	return f.locale
+}
+
+// FormatNumber formats the number using the given number of decimals
+func (f *Formatter) FormatNumber(v float64, decimals int) string {
+	s := strconv.FormatFloat(math.Abs(v), 'f', decimals, 64)
+	integer, fraction, _ := strings.Cut(s, ".")
+
+	groups := []string{}
+	for len(integer) > 3 {
+		groups = append([]string{integer[len(integer)-3:]}, groups...)
+		integer = integer[:len(integer)-3]
+	}
+	out := strings.Join(append([]string{integer}, groups...), f.rules.Group)
+	if fraction != "" {
+		out += f.rules.Decimal + fraction
+	}
+	if v < 0 && strings.Trim(s, "0.") != "" {
+		out = "-" + out
+	}
+	return out
+}
+
+// FormatCurrency formats the amount of the given ISO 4217 currency;
+// currencies without a well known symbol are displayed using their code.
+// Symbols are separated using a non-breaking space.
+func (f *Formatter) FormatCurrency(amount float64, currency string) string {
+	symbol, ok := currencySymbols[currency]
+	if !ok {
+		symbol = currency
+	}
+	number := f.FormatNumber(amount, 2)
+	if f.rules.CurrencySuffix {
+		return number + "\u00a0" + symbol
+	}
+	if !ok {
+		// codes need to be separated from the amount
+		return symbol + "\u00a0" + number
+	}
+	return symbol + number
+}
+
+// FormatDate formats the date of the given time
+func (f *Formatter) FormatDate(t time.Time) string {
+	return t.Format(f.rules.DateLayout)
+}
@@ -0,0 +1,62 @@
+package jonson
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFormatter(t *testing.T) {
+	date := time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		locale   string
+		number   string
+		currency string
+		date     string
+	}{
+		{"en-US", "-1,234,567.891", "$1,234.50", "03/14/2024"},
+		{"de-DE", "-1.234.567,891", "1.234,50\u00a0€", "14.03.2024"},
+		{"de-CH", "-1’234’567.891", "CHF\u00a01’234.50", "14.03.2024"},
+	}
+	currencies := map[string]string{"en-US": "USD", "de-DE": "EUR", "de-CH": "CHF"}
+
+	for _, tt := range tests {
+		f := NewFormatter(NewLocale(tt.locale))
+		t.Run("expect "+tt.locale+" formatting", func(t *testing.T) {
+			if out := f.FormatNumber(-1234567.891, 3); out != tt.number {
+				t.Fatalf("expected number %s, got: %s", tt.number, out)
+			}
+			if out := f.FormatCurrency(1234.5, currencies[tt.locale]); out != tt.currency {
+				t.Fatalf("expected currency %s, got: %s", tt.currency, out)
+			}
+			if out := f.FormatDate(date); out != tt.date {
+				t.Fatalf("expected date %s, got: %s", tt.date, out)
+			}
+		})
+	}
+
+	t.Run("expect unknown locales to fall back", func(t *testing.T) {
+		if tag := NewLocale("de-AT").Tag; tag != "de-DE" {
+			t.Fatalf("expected language fallback de-DE, got: %s", tag)
+		}
+		if tag := NewLocale("ja-JP").Tag; tag != DefaultLocale {
+			t.Fatalf("expected default locale, got: %s", tag)
+		}
+	})
+
+	t.Run("expect locale to be provided using the accept language header", func(t *testing.T) {
+		fac := NewFactory()
+		fac.RegisterProviderFunc(ProvideLocale)
+		fac.RegisterProviderFunc(ProvideFormatter)
+		ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Language", "ja;q=1.0, de-ch;q=0.9, en;q=0.8")
+		ctx.StoreValue(TypeHTTPRequest, r)
+		if tag := RequireFormatter(ctx).Locale().Tag; tag != "de-CH" {
+			t.Fatalf("expected de-CH, got: %s", tag)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package jonson
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,18 +10,99 @@ import (
 	"net/http"
 	"reflect"
 	"regexp"
+	"runtime/debug"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // MethodDefinition is used by MustRegisterAPI
 type MethodDefinition struct {
-	System        string
-	Method        string
-	Version       uint64
-	HandlerFunc   any
+	System      string
+	Method      string
+	Version     uint64
+	HandlerFunc any
+	// ContentTypes defines the content types the method's params
+	// can be decoded from; defaults to json
+	ContentTypes []string
+	// Summary and Description document the method;
+	// both are exposed within the OpenRPC document
+	Summary     string
+	Description string
+	// Timeout defines the method's deadline;
+	// defaults to the method handler's method timeout
+	Timeout time.Duration
+	// Group allows us to enable or disable multiple methods at once,
+	// see SetGroupEnabled
+	Group string
+	// Idempotent methods will be retried on transient errors,
+	// see SetRetry
+	Idempotent bool
+	// QueryParams allows http methods to be called using GET;
+	// the params will be decoded from the url's query string
+	QueryParams bool
+	// Examples document example calls of the method,
+	// see ExampleCall
+	Examples      []*MethodExample
 	methodContext reflect.Value
 }
 
+// MethodOption allows us to attach metadata to a method
+type MethodOption func(def *MethodDefinition)
+
+// Summary sets a short summary of the method
+func Summary(summary string) MethodOption {
+	return func(def *MethodDefinition) {
+		def.Summary = summary
+	}
+}
+
+// Timeout sets the time the method may take
+func Timeout(timeout time.Duration) MethodOption {
+	return func(def *MethodDefinition) {
+		def.Timeout = timeout
+	}
+}
+
+// Idempotent marks the method as idempotent
+func Idempotent() MethodOption {
+	return func(def *MethodDefinition) {
+		def.Idempotent = true
+	}
+}
+
+// QueryParams allows the method to receive its params
+// using the url's query string
+func QueryParams() MethodOption {
+	return func(def *MethodDefinition) {
+		def.QueryParams = true
+	}
+}
+
+// AcceptContentTypes restricts the content types the method's params
+// can be decoded from; other content types will be rejected
+// using ErrUnsupportedContentType
+func AcceptContentTypes(types ...string) MethodOption {
+	return func(def *MethodDefinition) {
+		def.ContentTypes = types
+	}
+}
+
+// Group adds the method to the given group
+func Group(group string) MethodOption {
+	return func(def *MethodDefinition) {
+		def.Group = group
+	}
+}
+
+// Description sets a verbose description of the method
+func Description(description string) MethodOption {
+	return func(def *MethodDefinition) {
+		def.Description = description
+	}
+}
+
 var (
 	validIdentifierName = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
 	matchMethodName     = regexp.MustCompile(`^(.+)V([0-9]+)$`)
@@ -43,6 +125,8 @@ type apiEndpoint struct {
 	methodContext reflect.Value
 	paramsPos     int
 	paramsType    reflect.Type
+	resultType    reflect.Type
+	validator     *validator
 }
 
 type MethodHandler struct {
@@ -52,6 +136,38 @@ type MethodHandler struct {
 	systems      map[reflect.Type]any
 	endpoints    map[string]apiEndpoint
 	errorEncoder Secret
+	decoders     map[string]PayloadDecoder
+	codecs       map[string]ResponseCodec
+	errors       []*Error
+
+	provisionLimit         int
+	queryWarnThreshold     int
+	echoParamsMaxSize      int
+	methodTimeout          time.Duration
+	timeoutWarning         float64
+	openRPCInfo            OpenRPCInfo
+	observer               Observer
+	accessLogger           AccessLogger
+	templateRenderer       TemplateRenderer
+	strictErrors           bool
+	strictResults          bool
+	errorChain             bool
+	clock                  Clock
+	groupsMu               sync.RWMutex
+	disabledGroups         map[string]bool
+	retry                  *RetryOptions
+	router                 Router
+	envelopes              map[string]ResultEnvelope
+	envelopeSelector       func(r *http.Request) string
+	tracing                *TracingOptions
+	mounts                 []*mount
+	serverTiming           bool
+	batchPolicy            BatchPolicy
+	singletons             *singletonValues
+	pendingWrites          PendingWritesPolicy
+	streamDetailsThreshold int
+	clientDeadlines        *ClientDeadlineOptions
+	requestID              func() string
 }
 
 func GetDefaultMethodName(system string, method string, version uint64) string {
@@ -66,13 +182,83 @@ func NewMethodHandler(
 	if methodName == nil {
 		methodName = GetDefaultMethodName
 	}
-	return &MethodHandler{
-		provider:     provider,
-		methodName:   methodName,
-		systems:      map[reflect.Type]any{},
+	m := &MethodHandler{
+		provider:               provider,
+		methodName:             methodName,
+		systems:                map[reflect.Type]any{},
+		observer:               NopObserver{},
+		timeoutWarning:         DefaultTimeoutWarning,
+		requestID:              NewRequestID,
+		clock:                  SystemClock{},
+		streamDetailsThreshold: DefaultStreamDetailsThreshold,
+		disabledGroups:         map[string]bool{},
+		envelopes:              defaultResultEnvelopes(),
+		envelopeSelector:       selectResultEnvelope,
+		openRPCInfo: OpenRPCInfo{
+			Title:   "jonson",
+			Version: "0.0.0",
+		},
 		endpoints:    map[string]apiEndpoint{},
 		errorEncoder: errorEncoder,
+		decoders: map[string]PayloadDecoder{
+			ContentTypeJSON:    NewJSONDecoder(),
+			ContentTypeForm:    NewFormDecoder(),
+			ContentTypeMsgpack: NewMsgpackDecoder(),
+			ContentTypeQuery:   NewFormDecoder(),
+		},
+		codecs: map[string]ResponseCodec{
+			ContentTypeJSON:    NewJSONCodec(),
+			ContentTypeMsgpack: NewMsgpackCodec(),
+		},
 	}
+	m.singletons = newSingletonValues(m)
+	return m
+}
+
+// SetProvisionLimit sets the maximum number of values a single context
+// may provision; exceeding the limit will fail the call with ErrProvisionLimitExceeded.
+// The limit is a safety valve against runaway dependency graphs and
+// defaults to DefaultProvisionLimit.
+func (m *MethodHandler) SetProvisionLimit(limit int) {
+	m.provisionLimit = limit
+}
+
+// SetObserver sets the observer which gets notified
+// about events within the method handler
+func (m *MethodHandler) SetObserver(observer Observer) {
+	m.observer = observer
+}
+
+// SetEchoParamsOnError attaches the raw params (truncated to maxSize bytes)
+// to invalid params errors so you can see what has been sent by the client.
+// The params are encoded using the error encoder. Echoing params is meant
+// for development only and is disabled by default (maxSize <= 0).
+func (m *MethodHandler) SetEchoParamsOnError(maxSize int) {
+	m.echoParamsMaxSize = maxSize
+}
+
+// SetMethodTimeout sets the default timeout of all methods;
+// 0 disables the timeout (default). The timeout can be
+// overridden per method using the Timeout option.
+func (m *MethodHandler) SetMethodTimeout(timeout time.Duration) {
+	m.methodTimeout = timeout
+}
+
+// DefaultTimeoutWarning is the default fraction of a method's timeout
+// after which a timeout budget warning will be attached to the response
+const DefaultTimeoutWarning = 0.8
+
+// SetTimeoutWarning sets the fraction (0..1) of a method's timeout
+// after which a WarningTimeoutBudget warning will be attached to the response;
+// 0 disables the warning.
+func (m *MethodHandler) SetTimeoutWarning(fraction float64) {
+	m.timeoutWarning = fraction
+}
+
+// SetQueryWarnThreshold sets the number of queries a single request
+// may issue before a warning gets logged; 0 disables the warning.
+func (m *MethodHandler) SetQueryWarnThreshold(threshold int) {
+	m.queryWarnThreshold = threshold
 }
 
 // GetSystem returns a system. The function will panic in
@@ -123,7 +309,11 @@ func (m *MethodHandler) RegisterSystem(sys any, routeDebugger ...func(s string))
 }
 
 // RegisterMethod registers a new method
-func (m *MethodHandler) RegisterMethod(def *MethodDefinition) {
+func (m *MethodHandler) RegisterMethod(def *MethodDefinition, opts ...MethodOption) {
+	for _, opt := range opts {
+		opt(def)
+	}
+
 	if !validIdentifierName.MatchString(def.System) {
 		panic(errors.New("method handler: invalid system"))
 	}
@@ -143,7 +333,7 @@ func (m *MethodHandler) RegisterMethod(def *MethodDefinition) {
 	}
 
 	paramShift := 0
-	if !def.methodContext.IsNil() {
+	if def.methodContext.IsValid() && !def.methodContext.IsNil() {
 		// we have received a bounded method. we need to pass its context as first argument
 		paramShift = 1
 	}
@@ -166,6 +356,9 @@ func (m *MethodHandler) RegisterMethod(def *MethodDefinition) {
 		TypeHTTPResponseWriter,
 		TypeWSClient,
 		TypeSecret,
+		TypeQueryCounter,
+		TypeWarnings,
+		TypeCacheControl,
 	)
 
 	for i := paramShift; i < rt.NumIn(); i++ {
@@ -218,12 +411,167 @@ func (m *MethodHandler) RegisterMethod(def *MethodDefinition) {
 		panic(errors.New("method handler:" + handlerName + " must return error interface as last argument"))
 	}
 
+	var typeResult reflect.Type
+	if rt.NumOut() == 2 {
+		typeResult = rt.Out(0)
+	}
+
+	// validators are compiled once during registration
+	var paramsValidator *validator
+	if typeParams != nil {
+		paramsValidator = compileValidator(typeParams)
+	}
+
 	m.endpoints[endpoint] = apiEndpoint{
 		def:           def,
 		handlerFunc:   rv,
 		methodContext: def.methodContext,
 		paramsPos:     argPosParams,
 		paramsType:    typeParams,
+		resultType:    typeResult,
+		validator:     paramsValidator,
+	}
+}
+
+// SetStrictErrors enables the strict error mode meant for development:
+// methods returning errors whose code has not been registered using RegisterError
+// will be logged and the response will contain a WarningUnregisteredError warning.
+// jonson's predefined errors are registered implicitly. Disabled by default.
+func (m *MethodHandler) SetStrictErrors(strict bool) {
+	m.strictErrors = strict
+}
+
+// SetErrorChain enables encoding the whole chain of wrapped errors:
+// besides Debug, the error data will contain each layer unwrapped
+// using errors.Unwrap within Chain, each encoded using the secret.
+// Disabled by default.
+func (m *MethodHandler) SetErrorChain(enabled bool) {
+	m.errorChain = enabled
+}
+
+// encodeChain encodes each layer of the error chain;
+// layers only contain their own message without the wrapped error's one
+func (m *MethodHandler) encodeChain(err error) []string {
+	chain := []string{}
+	for err != nil {
+		next := errors.Unwrap(err)
+		msg := err.Error()
+		if next != nil {
+			msg = strings.TrimSuffix(msg, ": "+next.Error())
+		}
+		chain = append(chain, m.errorEncoder.Encode(msg))
+		err = next
+	}
+	return chain
+}
+
+// isRegisteredError returns true in case the error code has been registered
+func (m *MethodHandler) isRegisteredError(code int) bool {
+	for _, v := range rpcErrors {
+		if v.Code == code {
+			return true
+		}
+	}
+	for _, v := range m.errors {
+		if v.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRegisteredError reports unregistered errors in strict error mode
+func (m *MethodHandler) checkRegisteredError(ctx *Context, method string, err error) {
+	if !m.strictErrors || err == nil {
+		return
+	}
+	rpcErr := AsError(err)
+	if m.isRegisteredError(rpcErr.Code) {
+		return
+	}
+	msg := fmt.Sprintf("method %s returned unregistered error code %d (%s)", method, rpcErr.Code, rpcErr.Message)
+	log.Print("method handler: STRICT ERRORS: ", msg)
+	if v, ok := ctx.lookup(TypeWarnings); ok {
+		v.(*Warnings).Add(WarningUnregisteredError, msg)
+	}
+}
+
+// RegisterMethodIf registers the method only in case cond is true,
+// e.g. to register debug methods in development environments only
+func (m *MethodHandler) RegisterMethodIf(cond bool, def *MethodDefinition, opts ...MethodOption) {
+	if !cond {
+		return
+	}
+	m.RegisterMethod(def, opts...)
+}
+
+// SetGroupEnabled enables or disables all methods of the given group.
+// Disabled methods behave as if they were never registered:
+// calls fail with ErrMethodNotFound and the methods will not be listed within
+// the OpenRPC document. Groups are enabled by default.
+// Groups may be toggled at runtime, e.g. by a feature flag.
+func (m *MethodHandler) SetGroupEnabled(group string, enabled bool) {
+	m.groupsMu.Lock()
+	defer m.groupsMu.Unlock()
+	if enabled {
+		delete(m.disabledGroups, group)
+		return
+	}
+	m.disabledGroups[group] = true
+}
+
+// lookupEndpoint returns the endpoint of the given method
+// including the endpoints of mounted sub-handlers
+func (m *MethodHandler) lookupEndpoint(method string) (apiEndpoint, bool) {
+	if mnt, method, ok := m.mounted(method); ok {
+		return mnt.handler.lookupEndpoint(method)
+	}
+	return m.localEndpoint(method)
+}
+
+// localEndpoint returns the endpoint of a method registered within
+// the method handler; methods of disabled groups will not be returned
+func (m *MethodHandler) localEndpoint(method string) (apiEndpoint, bool) {
+	endpoint, ok := m.endpoints[method]
+	if !ok {
+		return apiEndpoint{}, false
+	}
+	if endpoint.def.Group != "" && m.groupDisabled(endpoint.def.Group) {
+		return apiEndpoint{}, false
+	}
+	return endpoint, true
+}
+
+func (m *MethodHandler) groupDisabled(group string) bool {
+	m.groupsMu.RLock()
+	defer m.groupsMu.RUnlock()
+	return m.disabledGroups[group]
+}
+
+// ConfigureMethod applies the given options to an already registered method.
+// This is helpful to attach metadata to methods registered by RegisterSystem.
+// The function will panic in case the method does not exist.
+func (m *MethodHandler) ConfigureMethod(method string, opts ...MethodOption) {
+	endpoint, ok := m.endpoints[method]
+	if !ok {
+		panic(fmt.Errorf("configureMethod: method %s does not exist", method))
+	}
+	for _, opt := range opts {
+		opt(endpoint.def)
+	}
+}
+
+// RegisterError registers errors which might be returned by methods.
+// Registered errors form the error catalog exposed within the OpenRPC document.
+// The function will panic in case an error code is registered twice.
+func (m *MethodHandler) RegisterError(errs ...*Error) {
+	for _, err := range errs {
+		for _, v := range m.errors {
+			if v.Code == err.Code {
+				panic(fmt.Errorf("registerError: error code %d already registered", err.Code))
+			}
+		}
+		m.errors = append(m.errors, err)
 	}
 }
 
@@ -300,66 +648,241 @@ func (m *MethodHandler) processMessages(r *http.Request, w http.ResponseWriter,
 		bindata = data[off+1:]
 	}
 
-	for _, _rpcRequest := range rpcRequests {
+	for i, _rpcRequest := range rpcRequests {
 		// try to unmarshal the request message into an
 		// rpc request format
 		rpcRequest := &RPCRequest{}
+		failed := false
 		if err := json.Unmarshal(_rpcRequest, rpcRequest); err != nil {
 			log.Print("method handler: parse error: ", err)
 			resp = append(resp, NewRPCErrorResponse(nil, ErrParse))
-			continue
-		}
-		if rpcResponse := m.processMessage(r, w, ws, rpcRequest, bindata); rpcResponse != nil {
+			failed = true
+		} else if rpcResponse := m.processMessage(r, w, ws, rpcRequest, bindata); rpcResponse != nil {
 			// ares is nil if we don't have to add a response (notifications)
 			resp = append(resp, rpcResponse)
+			_, failed = rpcResponse.(*RPCErrorResponse)
 		}
 		// even if we had bindata set, make sure to clear it after passing it to the first handler
 		bindata = nil
+
+		if failed && m.batchPolicy == BatchStopOnError && i < len(rpcRequests)-1 {
+			log.Printf("method handler: batch aborted after call %d failed", i)
+			resp = append(resp, abortBatch(rpcRequests[i+1:])...)
+			break
+		}
 	}
 
 	return
 }
 
 func (m *MethodHandler) processMessage(r *http.Request, w http.ResponseWriter, ws *WSClient, rpcRequest *RPCRequest, bindata []byte) any {
+	parent := r.Context()
+	timeout := m.timeout(rpcRequest.Method)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		parent, cancel = context.WithTimeout(parent, timeout)
+		defer cancel()
+	}
+	if clientTimeout, ok := m.clientTimeout(r); ok {
+		var cancel context.CancelFunc
+		parent, cancel = context.WithTimeout(parent, clientTimeout)
+		defer cancel()
+	}
+
+	requestID := m.newRequestID(r, ws)
+	idempotent := false
+	if endpoint, ok := m.lookupEndpoint(rpcRequest.Method); ok {
+		idempotent = endpoint.def.Idempotent
+	}
+
+	// do the actual api call; idempotent methods
+	// will be retried on transient errors
+	start := time.Now()
+	started := m.clock.Now()
+	var (
+		res any
+		err error
+		// only the last attempt's warnings and directives make it into the response
+		warnings     *Warnings
+		cacheControl *CacheControl
+	)
+	for attempt := 1; ; attempt++ {
+		res, warnings, cacheControl, err = m.attempt(parent, r, w, ws, rpcRequest, bindata, requestID)
+		if err == nil || !idempotent || !m.retry.retry(attempt, err) {
+			break
+		}
+		log.Printf("method handler: retrying %s after transient error (attempt %d): %s", rpcRequest.Method, attempt, err)
+		if !m.retry.wait(parent, attempt) {
+			break
+		}
+	}
+
+	if m.accessLogger != nil {
+		m.accessLogger.LogAccess(&AccessLogEntry{
+			RequestID: requestID,
+			Method:    rpcRequest.Method,
+			Duration:  time.Since(start),
+			Err:       err,
+		})
+	}
+
+	if timeout > 0 && m.timeoutWarning > 0 {
+		if elapsed := m.clock.Now().Sub(started); float64(elapsed) >= float64(timeout)*m.timeoutWarning {
+			warnings.Add(WarningTimeoutBudget, fmt.Sprintf("method took %v of its %v timeout", elapsed.Round(time.Millisecond), timeout))
+		}
+	}
+
+	var duration *time.Duration
+	if m.serverTiming {
+		d := m.clock.Now().Sub(started)
+		duration = &d
+	}
+
+	// error response
+	if err != nil {
+		if requestIncomplete(r) {
+			// the client disconnected while the body was read
+			incomplete := ErrRequestIncomplete.CloneWithData(nil)
+			incomplete.cause = err
+			err = incomplete
+		}
+		errResp := NewRPCErrorResponse(rpcRequest.ID, m.echoParams(m.encodeCause(AsError(err)), rpcRequest))
+		errResp.Warnings = warnings.List()
+		errResp.duration = duration
+		return errResp
+	}
+
+	if rpcRequest.ID == nil {
+		// jsonrpc 2.0 notification
+		return nil
+	}
+
+	meta := &ResultMeta{
+		Warnings: warnings.List(),
+	}
+	if duration != nil {
+		ms := durationMillis(*duration)
+		meta.Duration = &ms
+	}
+	resultResp := NewRPCResultResponse(rpcRequest.ID, m.wrapResult(r, res, meta))
+	resultResp.Warnings = meta.Warnings
+	resultResp.duration = duration
+	resultResp.cacheControl = cacheControl
+	return resultResp
+}
+
+// attempt calls the method using a fresh context
+// which will be finalized before returning; the warnings and
+// cache directives recorded by the attempt are returned along with the result
+func (m *MethodHandler) attempt(parent context.Context, r *http.Request, w http.ResponseWriter, ws *WSClient, rpcRequest *RPCRequest, bindata []byte, requestID string) (any, *Warnings, *CacheControl, error) {
+	warnings := NewWarnings()
+	cacheControl := NewCacheControl()
+
 	// create bounded context and store request details
-	ctx := NewContext(r.Context(), m.provider, m)
+	ctx := NewContext(parent, m.provider, m)
 	ctx.StoreValue(TypeHTTPRequest, r)
 	ctx.StoreValue(TypeHTTPResponseWriter, w)
 	ctx.StoreValue(TypeWSClient, ws)
 	ctx.StoreValue(TypeSecret, m.errorEncoder)
 	ctx.StoreValue(TypeRPCMeta, &RPCMeta{
-		Method: rpcRequest.Method,
+		Method:    rpcRequest.Method,
+		RequestID: requestID,
 	})
+	ctx.StoreValue(TypeQueryCounter, NewQueryCounter(rpcRequest.Method, m.queryWarnThreshold))
+	ctx.StoreValue(TypeWarnings, warnings)
+	ctx.StoreValue(TypeCacheControl, cacheControl)
+	if ws != nil {
+		ctx.shared = ws.shared
+	}
 
-	// do the actual api call
 	res, err := m.callMethod(ctx, rpcRequest, bindata)
+	if err == nil {
+		err = m.checkResult(rpcRequest.Method, res)
+	}
+	if err == nil {
+		// render before finalizing so render errors fail the request
+		res, err = m.render(res)
+	}
+	if err == nil {
+		err = checkBinaryResult(res, rpcRequest)
+	}
 
 	// finalize our context
-	err = ctx.Finalize(err)
-
-	// error response
-	if err != nil {
-		if err, ok := err.(*Error); ok {
-			return NewRPCErrorResponse(rpcRequest.ID, err)
-		}
+	return res, warnings, cacheControl, ctx.Finalize(err)
+}
 
-		return NewRPCErrorResponse(rpcRequest.ID, ErrInternal.CloneWithData(&ErrorData{
-			Debug: m.errorEncoder.Encode(err.Error()),
-		}))
+// timeout returns the timeout of the given method
+func (m *MethodHandler) timeout(method string) time.Duration {
+	if endpoint, ok := m.lookupEndpoint(method); ok && endpoint.def.Timeout > 0 {
+		return endpoint.def.Timeout
 	}
+	return m.methodTimeout
+}
 
-	if rpcRequest.ID == nil {
-		// jsonrpc 2.0 notification
-		return nil
+// encodeCause encodes the message of the error's cause into the debug data;
+// the causes of the error's details are encoded as well
+func (m *MethodHandler) encodeCause(err *Error) *Error {
+	encode := err.cause != nil && (err.Data == nil || err.Data.Debug == "")
+	var details []*Error
+	if err.Data != nil {
+		for i, detail := range err.Data.Details {
+			if encoded := m.encodeCause(detail); encoded != detail {
+				if details == nil {
+					details = append([]*Error(nil), err.Data.Details...)
+				}
+				details[i] = encoded
+			}
+		}
+	}
+	if !encode && details == nil {
+		return err
 	}
 
-	return NewRPCResultResponse(rpcRequest.ID, res)
+	data := ErrorData{}
+	if err.Data != nil {
+		data = *err.Data
+	}
+	if encode {
+		data.Debug = m.errorEncoder.Encode(err.cause.Error())
+		if m.errorChain {
+			data.Chain = m.encodeChain(err.cause)
+		}
+	}
+	if details != nil {
+		data.Details = details
+	}
+	return err.CloneWithData(&data)
+}
 
+// echoParams attaches the raw params to invalid params errors
+// in case echoing params has been enabled
+func (m *MethodHandler) echoParams(err *Error, rpcRequest *RPCRequest) *Error {
+	if m.echoParamsMaxSize <= 0 || err.Code != ErrInvalidParams.Code {
+		return err
+	}
+	params := rpcRequest.Params
+	if len(params) > m.echoParamsMaxSize {
+		params = params[:m.echoParamsMaxSize]
+	}
+	data := ErrorData{}
+	if err.Data != nil {
+		data = *err.Data
+	}
+	data.Params = m.errorEncoder.Encode(string(params))
+	return err.CloneWithData(&data)
 }
 
 func (m *MethodHandler) callMethod(ctx *Context, rpcRequest *RPCRequest, bindata []byte) (any, error) {
+	if m.tracing != nil {
+		return m.traceMethod(ctx, rpcRequest, bindata)
+	}
+	return m.route(ctx, rpcRequest, bindata)
+}
+
+// dispatch calls the locally registered method
+func (m *MethodHandler) dispatch(ctx *Context, rpcRequest *RPCRequest, bindata []byte) (any, error) {
 	// retrieve rpc handler
-	handler, ok := m.endpoints[rpcRequest.Method]
+	handler, ok := m.localEndpoint(rpcRequest.Method)
 	if !ok {
 		log.Print("method handler: endpoint not found: ", rpcRequest.Method)
 		return nil, ErrMethodNotFound
@@ -372,7 +895,7 @@ func (m *MethodHandler) callMethod(ctx *Context, rpcRequest *RPCRequest, bindata
 		paramShift = 0
 	)
 
-	if !handler.methodContext.IsNil() {
+	if handler.methodContext.IsValid() && !handler.methodContext.IsNil() {
 		// we have a methodContext we need to pass as hidden first argument
 		args[0] = handler.methodContext
 		paramShift = 1
@@ -382,8 +905,27 @@ func (m *MethodHandler) callMethod(ctx *Context, rpcRequest *RPCRequest, bindata
 	for i := paramShift; i < rt.NumIn(); i++ {
 		// params
 		if i == handler.paramsPos {
+			if !handler.acceptsContentType(rpcRequest.contentType) {
+				log.Print("method handler: unsupported content type: ", rpcRequest.contentType)
+				return nil, ErrUnsupportedContentType.CloneWithData(&ErrorData{
+					Debug: m.errorEncoder.Encode("content type " + rpcRequest.contentType + " is not accepted"),
+				})
+			}
+			decoder, err := m.payloadDecoder(rpcRequest.contentType)
+			if err != nil {
+				log.Print("method handler: decoder error: ", err)
+				return nil, ErrInvalidParams.CloneWithData(&ErrorData{
+					Debug: m.errorEncoder.Encode(err.Error()),
+				})
+			}
 			params := reflect.New(handler.paramsType)
-			if err := rpcRequest.UnmarshalAndValidate(m.errorEncoder, params.Interface(), bindata); err != nil {
+			if err := rpcRequest.decode(decoder, m.errorEncoder, params.Interface(), bindata); err != nil {
+				log.Print("method handler: decode error: ", err)
+				return nil, err
+			}
+			err = toValidationError(handler.validator.validate(m.errorEncoder, params.Elem(), nil))
+			m.observer.ObserveValidation(rpcRequest.Method, err)
+			if err != nil {
 				log.Print("method handler: validation error: ", err)
 				return nil, err
 			}
@@ -412,9 +954,12 @@ func (m *MethodHandler) callMethod(ctx *Context, rpcRequest *RPCRequest, bindata
 		args[i] = reflect.ValueOf(v)
 	}
 
+	handlerResult, err := m.callHandler(ctx, rpcRequest.Method, handler.handlerFunc, args)
+	if err != nil {
+		return nil, err
+	}
+
 	var (
-		err           error
-		handlerResult = handler.handlerFunc.Call(args)
 		// error is either on position 1 (data, err) or position 0 (err)
 		errIndex = len(handlerResult) - 1
 		res      any
@@ -426,7 +971,8 @@ func (m *MethodHandler) callMethod(ctx *Context, rpcRequest *RPCRequest, bindata
 	}
 
 	if handlerResult[errIndex].Interface() != nil {
-		err = handlerResult[errIndex].Interface().(error)
+		err = contextError(ctx, rpcRequest.Method, handlerResult[errIndex].Interface().(error))
+		m.checkRegisteredError(ctx, rpcRequest.Method, err)
 	}
 
 	if err != nil {
@@ -439,6 +985,54 @@ func (m *MethodHandler) callMethod(ctx *Context, rpcRequest *RPCRequest, bindata
 	return nil, nil
 }
 
+// contextError maps context errors returned by methods respecting
+// cancellation: exceeding the deadline results in ErrTimeout, cancellation
+// (e.g. the client went away) results in ErrClientGone. The context's own
+// state takes precedence over the returned error so we can tell both apart
+// even if the method wrapped the error.
+func contextError(ctx *Context, method string, err error) error {
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+	cause := ctx.Err()
+	if cause == nil {
+		// the method used a context of its own
+		cause = err
+	}
+
+	var out *Error
+	switch {
+	case !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded):
+		return err
+	case errors.Is(cause, context.DeadlineExceeded):
+		log.Printf("method handler: %s exceeded its deadline", method)
+		out = ErrTimeout.CloneWithData(nil)
+	default:
+		// client initiated cancellations are expected,
+		// we do not log them to keep logs free of noise
+		out = ErrClientGone.CloneWithData(nil)
+	}
+	out.cause = err
+	return out
+}
+
+// callHandler calls the handler func using panic recovery;
+// panics are returned as internal errors carrying the request id
+func (m *MethodHandler) callHandler(ctx *Context, method string, fn reflect.Value, args []reflect.Value) (res []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			requestID := requestIDOf(ctx)
+			rerr := getRecoverError(r)
+			log.Printf("method handler: panic in %s (request id %s): %s\n%s", method, requestID, rerr, debug.Stack())
+			err = ErrInternal.CloneWithData(&ErrorData{
+				Debug:     m.errorEncoder.Encode(rerr.Error()),
+				RequestID: requestID,
+			})
+		}
+	}()
+	return fn.Call(args), nil
+}
+
 func getRecoverError(e any) error {
 	err, ok := e.(error)
 	if ok {
@@ -0,0 +1,395 @@
+package jonson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// callRPC calls the given method using the http rpc handler
+// and returns the decoded response
+func callRPC(t *testing.T, mh *MethodHandler, method string, params any) map[string]json.RawMessage {
+	t.Helper()
+	p, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(&RPCRequest{
+		Version: "2.0",
+		ID:      json.RawMessage("1"),
+		Method:  method,
+		Params:  p,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	NewHttpRpcHandler(mh, "/rpc").Handle(w, req)
+
+	out := map[string]json.RawMessage{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("failed to decode response %s: %s", w.Body.String(), err)
+	}
+	return out
+}
+
+type MethodHandlerTest struct{}
+
+type methodHandlerTestEchoV1Params struct {
+	Params
+	Name string `json:"name"`
+}
+
+func (m *MethodHandlerTest) EchoV1(ctx *Context, params *methodHandlerTestEchoV1Params) (string, error) {
+	return params.Name, nil
+}
+
+type methodHandlerTestSleepV1Params struct {
+	Params
+	Millis int `json:"millis"`
+}
+
+func (m *MethodHandlerTest) SleepV1(ctx *Context, params *methodHandlerTestSleepV1Params) error {
+	time.Sleep(time.Duration(params.Millis) * time.Millisecond)
+	return nil
+}
+
+func (m *MethodHandlerTest) PanicV1(ctx *Context) error {
+	panic("something went wrong")
+}
+
+func (m *MethodHandlerTest) WaitV1(ctx *Context) error {
+	<-ctx.Done()
+	return fmt.Errorf("waiting: %w", ctx.Err())
+}
+
+var errMethodHandlerTestConflict = &Error{Code: 1001, Message: "conflict"}
+
+type methodHandlerTestFailV1Params struct {
+	Params
+	Code int `json:"code"`
+}
+
+func (m *MethodHandlerTest) FailV1(ctx *Context, params *methodHandlerTestFailV1Params) error {
+	return &Error{Code: params.Code, Message: "failed"}
+}
+
+func TestMethodHandlerRegisterMethod(t *testing.T) {
+	t.Run("expect unbound functions to be callable", func(t *testing.T) {
+		mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+		mh.RegisterMethod(&MethodDefinition{
+			System:      "method-handler-test",
+			Method:      "echo",
+			Version:     1,
+			HandlerFunc: (&MethodHandlerTest{}).EchoV1,
+		})
+
+		resp := callRPC(t, mh, "method-handler-test/echo.v1", map[string]any{"name": "Silvio"})
+		if string(resp["result"]) != `"Silvio"` {
+			t.Fatalf("expected result to be Silvio, got: %s", resp["result"])
+		}
+	})
+}
+
+func TestMethodHandlerEchoParamsOnError(t *testing.T) {
+	secret := NewAESSecret("962C27B021AD53CC1110E81E6F6C09D7A14F7911C508A43A")
+	invalidParams := map[string]any{"unknown": "secret value"}
+
+	errorData := func(t *testing.T, resp map[string]json.RawMessage) *ErrorData {
+		t.Helper()
+		rpcErr := &Error{}
+		if err := json.Unmarshal(resp["error"], rpcErr); err != nil {
+			t.Fatal(err)
+		}
+		if rpcErr.Code != ErrInvalidParams.Code {
+			t.Fatalf("expected invalid params, got: %v", rpcErr)
+		}
+		if rpcErr.Data == nil {
+			return &ErrorData{}
+		}
+		return rpcErr.Data
+	}
+
+	t.Run("expect params not to be echoed by default", func(t *testing.T) {
+		mh := NewMethodHandler(NewFactory(), secret, nil)
+		mh.RegisterSystem(&MethodHandlerTest{})
+
+		data := errorData(t, callRPC(t, mh, "method-handler-test/echo.v1", invalidParams))
+		if data.Params != "" {
+			t.Fatalf("expected no params, got: %s", data.Params)
+		}
+	})
+
+	t.Run("expect params to be echoed encoded and truncated", func(t *testing.T) {
+		mh := NewMethodHandler(NewFactory(), secret, nil)
+		mh.RegisterSystem(&MethodHandlerTest{})
+		mh.SetEchoParamsOnError(10)
+
+		data := errorData(t, callRPC(t, mh, "method-handler-test/echo.v1", invalidParams))
+		if data.Params == "" || data.Params == `{"unknown"` {
+			t.Fatalf("expected params to be encoded, got: %s", data.Params)
+		}
+		decoded, err := secret.Decode(data.Params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded != `{"unknown"` {
+			t.Fatalf("expected truncated params, got: %s", decoded)
+		}
+	})
+}
+
+func TestMethodHandlerTimeoutWarning(t *testing.T) {
+	warnings := func(t *testing.T, resp map[string]json.RawMessage) []*Warning {
+		t.Helper()
+		out := []*Warning{}
+		if resp["warnings"] == nil {
+			return out
+		}
+		if err := json.Unmarshal(resp["warnings"], &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterSystem(&MethodHandlerTest{})
+	mh.ConfigureMethod("method-handler-test/sleep.v1", Timeout(100*time.Millisecond))
+
+	t.Run("expect warning in case method ran close to its timeout", func(t *testing.T) {
+		w := warnings(t, callRPC(t, mh, "method-handler-test/sleep.v1", map[string]any{"millis": 85}))
+		if len(w) != 1 || w[0].Code != WarningTimeoutBudget {
+			t.Fatalf("expected timeout budget warning, got: %v", w)
+		}
+	})
+
+	t.Run("expect no warning for fast methods", func(t *testing.T) {
+		w := warnings(t, callRPC(t, mh, "method-handler-test/sleep.v1", map[string]any{"millis": 0}))
+		if len(w) != 0 {
+			t.Fatalf("expected no warnings, got: %v", w)
+		}
+	})
+
+	t.Run("expect no warning without timeout", func(t *testing.T) {
+		w := warnings(t, callRPC(t, mh, "method-handler-test/echo.v1", map[string]any{"name": "Silvio"}))
+		if len(w) != 0 {
+			t.Fatalf("expected no warnings, got: %v", w)
+		}
+	})
+}
+
+func TestMethodHandlerPanicRequestID(t *testing.T) {
+	var entry *AccessLogEntry
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterSystem(&MethodHandlerTest{})
+	mh.SetAccessLogger(AccessLoggerFunc(func(e *AccessLogEntry) {
+		entry = e
+	}))
+
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"method-handler-test/panic.v1"}`)
+	req := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(body))
+	req.Header.Set(RequestIDHeader, "request-1")
+	w := httptest.NewRecorder()
+	NewHttpRpcHandler(mh, "/rpc").Handle(w, req)
+
+	resp := &struct {
+		Error *Error `json:"error"`
+	}{}
+	if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("expect panic to be returned as internal error", func(t *testing.T) {
+		if resp.Error == nil || resp.Error.Code != ErrInternal.Code {
+			t.Fatalf("expected internal error, got: %s", w.Body.String())
+		}
+	})
+
+	t.Run("expect request id within error data", func(t *testing.T) {
+		if resp.Error.Data == nil || resp.Error.Data.RequestID != "request-1" {
+			t.Fatalf("expected request id request-1, got: %s", w.Body.String())
+		}
+	})
+
+	t.Run("expect request id within access log", func(t *testing.T) {
+		if entry == nil || entry.RequestID != "request-1" || entry.Err == nil {
+			t.Fatalf("expected failed access log entry with request id request-1, got: %+v", entry)
+		}
+	})
+}
+
+func TestMethodHandlerStrictErrors(t *testing.T) {
+	logs := &bytes.Buffer{}
+	log.SetOutput(logs)
+	defer log.SetOutput(os.Stderr)
+
+	warnings := func(t *testing.T, resp map[string]json.RawMessage) []*Warning {
+		t.Helper()
+		out := []*Warning{}
+		if resp["warnings"] != nil {
+			if err := json.Unmarshal(resp["warnings"], &out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return out
+	}
+
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterSystem(&MethodHandlerTest{})
+	mh.RegisterError(errMethodHandlerTestConflict)
+
+	t.Run("expect unregistered errors to be ignored by default", func(t *testing.T) {
+		logs.Reset()
+		w := warnings(t, callRPC(t, mh, "method-handler-test/fail.v1", map[string]any{"code": 1002}))
+		if len(w) != 0 || strings.Contains(logs.String(), "STRICT ERRORS") {
+			t.Fatalf("expected no warnings, got: %v", w)
+		}
+	})
+
+	mh.SetStrictErrors(true)
+
+	t.Run("expect unregistered errors to be reported in strict mode", func(t *testing.T) {
+		logs.Reset()
+		w := warnings(t, callRPC(t, mh, "method-handler-test/fail.v1", map[string]any{"code": 1002}))
+		if len(w) != 1 || w[0].Code != WarningUnregisteredError {
+			t.Fatalf("expected unregistered error warning, got: %v", w)
+		}
+		if !strings.Contains(logs.String(), "unregistered error code 1002") {
+			t.Fatalf("expected unregistered error to be logged, got: %s", logs.String())
+		}
+	})
+
+	t.Run("expect registered and predefined errors to pass in strict mode", func(t *testing.T) {
+		for _, code := range []int{errMethodHandlerTestConflict.Code, ErrUnauthorized.Code} {
+			w := warnings(t, callRPC(t, mh, "method-handler-test/fail.v1", map[string]any{"code": code}))
+			if len(w) != 0 {
+				t.Fatalf("expected no warnings for code %d, got: %v", code, w)
+			}
+		}
+	})
+}
+
+func TestMethodHandlerConditionalRegistration(t *testing.T) {
+	expectMethodNotFound := func(t *testing.T, mh *MethodHandler, method string) {
+		t.Helper()
+		resp := callRPC(t, mh, method, map[string]any{"name": "Silvio"})
+		rpcErr := &Error{}
+		if err := json.Unmarshal(resp["error"], rpcErr); err != nil || rpcErr.Code != ErrMethodNotFound.Code {
+			t.Fatalf("expected method not found, got: %s", resp["error"])
+		}
+		doc, err := mh.OpenRPCDocument()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(doc, []byte(method)) {
+			t.Fatalf("expected %s to be absent from OpenRPC document", method)
+		}
+	}
+
+	newHandler := func() *MethodHandler {
+		return NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	}
+	def := func(method string) *MethodDefinition {
+		return &MethodDefinition{
+			System:      "method-handler-test",
+			Method:      method,
+			Version:     1,
+			HandlerFunc: (&MethodHandlerTest{}).EchoV1,
+		}
+	}
+
+	t.Run("expect method not to be registered in case condition is false", func(t *testing.T) {
+		mh := newHandler()
+		mh.RegisterMethodIf(false, def("debug"))
+		mh.RegisterMethodIf(true, def("echo"))
+
+		expectMethodNotFound(t, mh, "method-handler-test/debug.v1")
+		if resp := callRPC(t, mh, "method-handler-test/echo.v1", map[string]any{"name": "Silvio"}); resp["error"] != nil {
+			t.Fatalf("expected echo to be registered, got: %s", resp["error"])
+		}
+	})
+
+	t.Run("expect methods of disabled groups to be absent", func(t *testing.T) {
+		mh := newHandler()
+		mh.RegisterMethod(def("debug"), Group("admin"))
+		mh.RegisterMethod(def("inspect"), Group("admin"))
+		mh.SetGroupEnabled("admin", false)
+
+		expectMethodNotFound(t, mh, "method-handler-test/debug.v1")
+		expectMethodNotFound(t, mh, "method-handler-test/inspect.v1")
+
+		mh.SetGroupEnabled("admin", true)
+		if resp := callRPC(t, mh, "method-handler-test/debug.v1", map[string]any{"name": "Silvio"}); resp["error"] != nil {
+			t.Fatalf("expected debug to be enabled, got: %s", resp["error"])
+		}
+	})
+
+	t.Run("expect groups to be toggled while serving", func(t *testing.T) {
+		mh := newHandler()
+		mh.RegisterMethod(def("debug"), Group("admin"))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				mh.SetGroupEnabled("admin", i%2 == 0)
+			}
+		}()
+		for i := 0; i < 100; i++ {
+			callRPC(t, mh, "method-handler-test/debug.v1", map[string]any{"name": "Silvio"})
+		}
+		<-done
+	})
+}
+
+func TestMethodHandlerContextErrors(t *testing.T) {
+	logs := &bytes.Buffer{}
+	log.SetOutput(logs)
+	defer log.SetOutput(os.Stderr)
+
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterSystem(&MethodHandlerTest{})
+	mh.ConfigureMethod("method-handler-test/wait.v1", Timeout(20*time.Millisecond))
+
+	call := func(t *testing.T, parent context.Context) *Error {
+		t.Helper()
+		body := []byte(`{"jsonrpc":"2.0","id":1,"method":"method-handler-test/wait.v1"}`)
+		req := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(body)).WithContext(parent)
+		w := httptest.NewRecorder()
+		NewHttpRpcHandler(mh, "/rpc").Handle(w, req)
+		resp := &RPCErrorResponse{}
+		if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil || resp.Error == nil {
+			t.Fatalf("expected error response, got: %s", w.Body.String())
+		}
+		return resp.Error
+	}
+
+	t.Run("expect exceeded deadline to be mapped to timeout", func(t *testing.T) {
+		logs.Reset()
+		if err := call(t, context.Background()); err.Code != ErrTimeout.Code {
+			t.Fatalf("expected timeout error, got: %+v", err)
+		}
+		if !strings.Contains(logs.String(), "exceeded its deadline") {
+			t.Fatalf("expected timeout to be logged, got: %s", logs.String())
+		}
+	})
+
+	t.Run("expect cancellation to be mapped to client gone", func(t *testing.T) {
+		logs.Reset()
+		parent, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := call(t, parent); err.Code != ErrClientGone.Code {
+			t.Fatalf("expected client gone error, got: %+v", err)
+		}
+		if logs.Len() != 0 {
+			t.Fatalf("expected cancellation not to be logged, got: %s", logs.String())
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package jonson
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// MountOptions configure mounted sub-handlers
+type MountOptions struct {
+	// StripPrefix removes the prefix from the method's name
+	// before the call is passed to the sub-handler
+	StripPrefix bool
+}
+
+type mount struct {
+	prefix  string
+	handler *MethodHandler
+	options MountOptions
+}
+
+// mountedTypes are passed from the root's context to the sub-handler's context
+var mountedTypes = []reflect.Type{
+	TypeHTTPRequest,
+	TypeHTTPResponseWriter,
+	TypeWSClient,
+	TypeRPCMeta,
+	TypeQueryCounter,
+	TypeWarnings,
+	TypeCacheControl,
+}
+
+// Mount routes all calls whose method starts with prefix to the sub-handler;
+// each module of a modular monolith may thereby use its own method handler.
+// Mounted calls are executed within a context of the sub-handler, so the
+// sub-handler's registry, router, tracing, decoders and providers apply.
+// Transport related features (timeouts, retries, access logs, envelopes)
+// remain the ones of the root handler.
+// Create sub-handlers using the root's factory to share its singletons.
+// The longest matching prefix wins; mounts take precedence over the root's methods.
+func (m *MethodHandler) Mount(prefix string, sub *MethodHandler, options *MountOptions) {
+	if prefix == "" {
+		panic(errors.New("method handler: mount prefix must not be empty"))
+	}
+	if sub == nil || sub == m {
+		panic(errors.New("method handler: cannot mount " + prefix + " onto itself"))
+	}
+	for _, v := range m.mounts {
+		if v.prefix == prefix {
+			panic(errors.New("method handler: prefix " + prefix + " is already mounted"))
+		}
+	}
+	mnt := &mount{
+		prefix:  prefix,
+		handler: sub,
+	}
+	if options != nil {
+		mnt.options = *options
+	}
+	m.mounts = append(m.mounts, mnt)
+}
+
+// mounted returns the mount responsible for the given method
+// as well as the method's name within the sub-handler
+func (m *MethodHandler) mounted(method string) (*mount, string, bool) {
+	var found *mount
+	for _, v := range m.mounts {
+		if strings.HasPrefix(method, v.prefix) && (found == nil || len(v.prefix) > len(found.prefix)) {
+			found = v
+		}
+	}
+	if found == nil {
+		return nil, "", false
+	}
+	if found.options.StripPrefix {
+		method = strings.TrimPrefix(method, found.prefix)
+	}
+	return found, method, true
+}
+
+// dispatch calls the method within a context of the sub-handler
+func (mnt *mount) dispatch(ctx *Context, method string, rpcRequest *RPCRequest, bindata []byte) (any, error) {
+	sub := NewContext(ctx, mnt.handler.provider, mnt.handler)
+	sub.shared = ctx.shared
+	sub.singletons = ctx.singletons
+	sub.span = ctx.span
+	sub.StoreValue(TypeSecret, mnt.handler.errorEncoder)
+	for _, rt := range mountedTypes {
+		if _, ok := ctx.lookup(rt); ok {
+			if err := sub.Merge(ctx, rt); err != nil {
+				return nil, sub.Finalize(err)
+			}
+		}
+	}
+
+	req := *rpcRequest
+	req.Method = method
+	res, err := mnt.handler.callMethod(sub, &req, bindata)
+	return res, sub.Finalize(err)
+}
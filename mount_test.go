@@ -0,0 +1,84 @@
+package jonson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type mountTestConfig struct {
+	name string
+}
+
+type MountTestInvoice struct{}
+
+func (m *MountTestInvoice) GetV1(ctx *Context) (string, error) {
+	return "invoice of " + Require[*mountTestConfig](ctx).name, nil
+}
+
+type MountTestShipping struct{}
+
+func (m *MountTestShipping) GetV1(ctx *Context) (string, error) {
+	return "shipping of " + Require[*mountTestConfig](ctx).name, nil
+}
+
+func TestMount(t *testing.T) {
+	config := &mountTestConfig{name: "acme"}
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *mountTestConfig {
+		return config
+	})
+
+	billing := NewMethodHandler(fac, NewDebugSecret(), nil)
+	billing.RegisterSystem(&MountTestInvoice{})
+	billingCalls := 0
+	billing.SetRouter(RouterFunc(func(ctx *Context, method string) (Dispatcher, error) {
+		billingCalls++
+		return billing, nil
+	}))
+
+	shipping := NewMethodHandler(fac, NewDebugSecret(), nil)
+	shipping.RegisterSystem(&MountTestShipping{})
+
+	root := NewMethodHandler(fac, NewDebugSecret(), nil)
+	root.Mount("billing/", billing, &MountOptions{StripPrefix: true})
+	root.Mount("mount-test-shipping/", shipping, nil)
+
+	result := func(t *testing.T, resp map[string]json.RawMessage) string {
+		t.Helper()
+		out := ""
+		if err := json.Unmarshal(resp["result"], &out); err != nil {
+			t.Fatalf("expected result, got: %s", resp["error"])
+		}
+		return out
+	}
+
+	t.Run("expect stripped prefix to be routed to the sub-handler", func(t *testing.T) {
+		if out := result(t, callRPC(t, root, "billing/mount-test-invoice/get.v1", nil)); out != "invoice of acme" {
+			t.Fatalf("expected invoice of acme, got: %s", out)
+		}
+	})
+
+	t.Run("expect kept prefix to be routed to the sub-handler", func(t *testing.T) {
+		if out := result(t, callRPC(t, root, "mount-test-shipping/get.v1", nil)); out != "shipping of acme" {
+			t.Fatalf("expected shipping of acme, got: %s", out)
+		}
+	})
+
+	t.Run("expect the sub-handler's router to apply to its calls only", func(t *testing.T) {
+		if billingCalls != 1 {
+			t.Fatalf("expected a single call to be routed by billing, got: %d", billingCalls)
+		}
+	})
+
+	t.Run("expect registries to be isolated", func(t *testing.T) {
+		resp := callRPC(t, root, "mount-test-invoice/get.v1", nil)
+		rpcErr := &Error{}
+		if err := json.Unmarshal(resp["error"], rpcErr); err != nil || rpcErr.Code != ErrMethodNotFound.Code {
+			t.Fatalf("expected method not found, got: %s", resp["error"])
+		}
+		resp = callRPC(t, root, "billing/mount-test-shipping/get.v1", nil)
+		if err := json.Unmarshal(resp["error"], rpcErr); err != nil || rpcErr.Code != ErrMethodNotFound.Code {
+			t.Fatalf("expected method not found, got: %s", resp["error"])
+		}
+	})
+}
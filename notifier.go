@@ -0,0 +1,134 @@
+package jonson
+
+import (
+	"errors"
+	"log"
+	"sync"
+)
+
+// ErrNotificationQueueFull is returned by Notify in case the
+// notifier's queue is full
+var ErrNotificationQueueFull = errors.New("notifier: queue is full")
+
+// Notification is a notification which will be fanned out
+// to all subscribers of the topic
+type Notification struct {
+	Topic        string
+	Notification *RPCNotification
+}
+
+// NotificationBroker delivers notifications to subscribers,
+// e.g. an in-process hub or a pub/sub system
+type NotificationBroker interface {
+	Publish(notifications []*Notification) error
+}
+
+// Notifier queues notifications during a request.
+// The notifications will be published once the context
+// has been finalized successfully; in case the request fails,
+// the notifications will be dropped.
+// Provide the notifier using a provider, e.g.:
+//
+//	fac.RegisterProviderFunc(func(ctx *jonson.Context) *jonson.Notifier {
+//		return jonson.NewNotifier(ctx, hub, 100)
+//	})
+type Notifier struct {
+	broker  NotificationBroker
+	maxSize int
+	mu      sync.Mutex
+	queue   []*Notification
+}
+
+// NewNotifier returns a new notifier bound to the given context;
+// the notifier queues up to maxSize notifications
+func NewNotifier(ctx *Context, broker NotificationBroker, maxSize int) *Notifier {
+	n := &Notifier{
+		broker:  broker,
+		maxSize: maxSize,
+	}
+	ctx.AfterFinalize(n.deliver)
+	return n
+}
+
+// Notify queues a notification for the given topic
+func (n *Notifier) Notify(topic string, method string, payload any) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if len(n.queue) >= n.maxSize {
+		return ErrNotificationQueueFull
+	}
+	n.queue = append(n.queue, &Notification{
+		Topic:        topic,
+		Notification: NewRPCNotification(method, payload),
+	})
+	return nil
+}
+
+func (n *Notifier) deliver(err error) {
+	n.mu.Lock()
+	queue := n.queue
+	n.queue = nil
+	n.mu.Unlock()
+
+	if err != nil || len(queue) == 0 {
+		return
+	}
+	if err := n.broker.Publish(queue); err != nil {
+		log.Print("notifier: failed to publish notifications: ", err)
+	}
+}
+
+// NotificationSubscriber receives notifications; implemented by *WSClient
+type NotificationSubscriber interface {
+	SendNotification(msg *RPCNotification) error
+}
+
+var _ NotificationSubscriber = (*WSClient)(nil)
+
+// NotificationHub is an in-process NotificationBroker
+// delivering notifications to subscribed clients
+type NotificationHub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[NotificationSubscriber]struct{}
+}
+
+func NewNotificationHub() *NotificationHub {
+	return &NotificationHub{
+		subscribers: map[string]map[NotificationSubscriber]struct{}{},
+	}
+}
+
+// Subscribe subscribes the subscriber to the given topic
+func (h *NotificationHub) Subscribe(topic string, s NotificationSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[topic]; !ok {
+		h.subscribers[topic] = map[NotificationSubscriber]struct{}{}
+	}
+	h.subscribers[topic][s] = struct{}{}
+}
+
+// Unsubscribe removes the subscriber from the given topic
+func (h *NotificationHub) Unsubscribe(topic string, s NotificationSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers[topic], s)
+	if len(h.subscribers[topic]) == 0 {
+		delete(h.subscribers, topic)
+	}
+}
+
+func (h *NotificationHub) Publish(notifications []*Notification) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var errs []error
+	for _, n := range notifications {
+		for s := range h.subscribers[n.Topic] {
+			if err := s.SendNotification(n.Notification); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,66 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type notifierTestSubscriber struct {
+	received []*RPCNotification
+}
+
+func (n *notifierTestSubscriber) SendNotification(msg *RPCNotification) error {
+	n.received = append(n.received, msg)
+	return nil
+}
+
+func TestNotifier(t *testing.T) {
+	fac := NewFactory()
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	setup := func() (*Context, *Notifier, *notifierTestSubscriber, *notifierTestSubscriber) {
+		hub := NewNotificationHub()
+		subscriber := &notifierTestSubscriber{}
+		other := &notifierTestSubscriber{}
+		hub.Subscribe("posts", subscriber)
+		hub.Subscribe("comments", other)
+		ctx := NewContext(context.Background(), fac, mh)
+		return ctx, NewNotifier(ctx, hub, 2), subscriber, other
+	}
+
+	t.Run("expect notifications to be delivered on success", func(t *testing.T) {
+		ctx, notifier, subscriber, other := setup()
+		if err := notifier.Notify("posts", "posts/created", map[string]string{"user": "x"}); err != nil {
+			t.Fatal(err)
+		}
+		if len(subscriber.received) != 0 {
+			t.Fatal("expected notifications not to be delivered before finalize")
+		}
+		ctx.Finalize(nil)
+		if len(subscriber.received) != 1 || subscriber.received[0].Method != "posts/created" {
+			t.Fatalf("expected a single notification, got: %v", subscriber.received)
+		}
+		if len(other.received) != 0 {
+			t.Fatal("expected other topics not to receive notifications")
+		}
+	})
+
+	t.Run("expect notifications to be dropped on failure", func(t *testing.T) {
+		ctx, notifier, subscriber, _ := setup()
+		notifier.Notify("posts", "posts/created", nil)
+		ctx.Finalize(errors.New("failed"))
+		if len(subscriber.received) != 0 {
+			t.Fatalf("expected notifications to be dropped, got: %v", subscriber.received)
+		}
+	})
+
+	t.Run("expect queue to be bounded", func(t *testing.T) {
+		_, notifier, _, _ := setup()
+		notifier.Notify("posts", "posts/created", nil)
+		notifier.Notify("posts", "posts/created", nil)
+		if err := notifier.Notify("posts", "posts/created", nil); !errors.Is(err, ErrNotificationQueueFull) {
+			t.Fatalf("expected queue to be full, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package jonson
+
+import "reflect"
+
+// Observer gets notified about events happening within the method handler.
+// Observers can be used to collect stats and metrics;
+// embed NopObserver to only implement the events you are interested in.
+type Observer interface {
+	// ObserveValidation is called after the params of a method
+	// have been validated; err is nil in case validation succeeded
+	ObserveValidation(method string, err error)
+	// ObserveProvisionFailure is called in case provisioning a value
+	// of the given type failed; class is taken from the ProvideError
+	// returned by the provider, see ClassifyProvideError
+	ObserveProvisionFailure(rt reflect.Type, class ProvideErrorClass, err error)
+}
+
+// NopObserver implements the Observer interface without doing anything
+type NopObserver struct{}
+
+var _ Observer = NopObserver{}
+
+func (NopObserver) ObserveValidation(method string, err error) {}
+
+func (NopObserver) ObserveProvisionFailure(rt reflect.Type, class ProvideErrorClass, err error) {}
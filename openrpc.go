@@ -0,0 +1,311 @@
+package jonson
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenRPCVersion defines the OpenRPC specification version
+// of the generated documents
+const OpenRPCVersion = "1.2.6"
+
+// OpenRPCInfo contains the info object of the OpenRPC document
+type OpenRPCInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type openRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       OpenRPCInfo       `json:"info"`
+	Methods    []*openRPCMethod  `json:"methods"`
+	Components openRPCComponents `json:"components"`
+}
+
+type openRPCComponents struct {
+	Schemas map[string]any           `json:"schemas,omitempty"`
+	Errors  map[string]*openRPCError `json:"errors,omitempty"`
+}
+
+type openRPCMethod struct {
+	Name           string                      `json:"name"`
+	Summary        string                      `json:"summary,omitempty"`
+	Description    string                      `json:"description,omitempty"`
+	ParamStructure string                      `json:"paramStructure"`
+	Params         []*openRPCContentDescriptor `json:"params"`
+	Result         *openRPCContentDescriptor   `json:"result"`
+	Examples       []*openRPCExample           `json:"examples,omitempty"`
+}
+
+type openRPCExample struct {
+	Name   string                 `json:"name"`
+	Params []*openRPCExampleValue `json:"params"`
+	Result *openRPCExampleValue   `json:"result"`
+}
+
+type openRPCExampleValue struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+type openRPCContentDescriptor struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required,omitempty"`
+	Schema   any    `json:"schema"`
+}
+
+type openRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// SetOpenRPCInfo sets the info object of the generated OpenRPC document
+func (m *MethodHandler) SetOpenRPCInfo(info OpenRPCInfo) {
+	m.openRPCInfo = info
+}
+
+// OpenRPCDocument generates an OpenRPC document describing all registered methods.
+// Param and result schemas are derived from the method's types,
+// errors are taken from the errors registered using RegisterError.
+func (m *MethodHandler) OpenRPCDocument() ([]byte, error) {
+	schemas := newOpenRPCSchemas()
+	doc := &openRPCDocument{
+		OpenRPC: OpenRPCVersion,
+		Info:    m.openRPCInfo,
+		Methods: []*openRPCMethod{},
+	}
+
+	names := make([]string, 0, len(m.endpoints))
+	for name := range m.endpoints {
+		if _, ok := m.localEndpoint(name); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		endpoint := m.endpoints[name]
+		method := &openRPCMethod{
+			Name:           name,
+			Summary:        endpoint.def.Summary,
+			Description:    endpoint.def.Description,
+			ParamStructure: "by-name",
+			Params:         []*openRPCContentDescriptor{},
+			Result: &openRPCContentDescriptor{
+				Name:   "result",
+				Schema: map[string]any{"type": "null"},
+			},
+		}
+		if endpoint.paramsType != nil {
+			for _, field := range schemas.fields(endpoint.paramsType) {
+				method.Params = append(method.Params, &openRPCContentDescriptor{
+					Name:     field.name,
+					Required: field.required,
+					Schema:   field.schema,
+				})
+			}
+		}
+		if endpoint.resultType != nil {
+			method.Result.Schema = schemas.schema(endpoint.resultType)
+		}
+		for _, example := range endpoint.def.Examples {
+			e, err := m.openRPCExample(method, example)
+			if err != nil {
+				return nil, err
+			}
+			method.Examples = append(method.Examples, e)
+		}
+		doc.Methods = append(doc.Methods, method)
+	}
+
+	doc.Components.Schemas = schemas.schemas
+	if len(m.errors) > 0 {
+		doc.Components.Errors = map[string]*openRPCError{}
+		for _, v := range m.errors {
+			doc.Components.Errors[strconv.Itoa(v.Code)] = &openRPCError{
+				Code:    v.Code,
+				Message: v.Message,
+			}
+		}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// openRPCExample converts the example into an example pairing object;
+// params are listed by name in the order of the method's params
+func (m *MethodHandler) openRPCExample(method *openRPCMethod, example *MethodExample) (*openRPCExample, error) {
+	out := &openRPCExample{
+		Name:   example.Name,
+		Params: []*openRPCExampleValue{},
+	}
+	params, err := m.exampleValue(example.Params)
+	if err != nil {
+		return nil, err
+	}
+	if values, ok := params.(map[string]any); ok {
+		for _, p := range method.Params {
+			if v, ok := values[p.Name]; ok {
+				out.Params = append(out.Params, &openRPCExampleValue{Name: p.Name, Value: v})
+			}
+		}
+	}
+	result, err := m.exampleValue(example.Result)
+	if err != nil {
+		return nil, err
+	}
+	out.Result = &openRPCExampleValue{Name: "result", Value: result}
+	return out, nil
+}
+
+var (
+	typeTime           = reflect.TypeOf(time.Time{})
+	typeJSONRawMessage = reflect.TypeOf(json.RawMessage{})
+	invalidSchemaName  = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// openRPCSchemas collects the json schemas of named structs
+// which will be referenced from within the methods
+type openRPCSchemas struct {
+	names   map[reflect.Type]string
+	schemas map[string]any
+}
+
+type openRPCField struct {
+	name     string
+	required bool
+	schema   any
+}
+
+func newOpenRPCSchemas() *openRPCSchemas {
+	return &openRPCSchemas{
+		names:   map[reflect.Type]string{},
+		schemas: map[string]any{},
+	}
+}
+
+// schema returns the json schema of the given type
+func (s *openRPCSchemas) schema(rt reflect.Type) any {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+
+	switch {
+	case rt == typeTime:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rt == typeJSONRawMessage:
+		return map[string]any{}
+	case rt == typeHTMLResult:
+		return map[string]any{"type": "string", "contentMediaType": ContentTypeHTML}
+	case rt == typeBinaryResult:
+		return map[string]any{"type": "string", "contentMediaType": ContentTypeOctetStream}
+	}
+
+	switch rt.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if rt.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": s.schema(rt.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schema(rt.Elem())}
+	case reflect.Struct:
+		if rt.Name() == "" {
+			return s.object(rt)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + s.define(rt)}
+	}
+
+	// interfaces and everything we cannot describe
+	return map[string]any{}
+}
+
+// define adds the named struct to the schema components
+// and returns its name
+func (s *openRPCSchemas) define(rt reflect.Type) string {
+	if name, ok := s.names[rt]; ok {
+		return name
+	}
+
+	name := invalidSchemaName.ReplaceAllString(rt.Name(), "_")
+	if _, exists := s.schemas[name]; exists {
+		// same name within different packages
+		pkg := rt.PkgPath()
+		name = ToPascalCase(invalidSchemaName.ReplaceAllString(pkg[strings.LastIndex(pkg, "/")+1:], "_")) + name
+	}
+
+	// register name before walking fields to support recursive types
+	s.names[rt] = name
+	s.schemas[name] = nil
+	s.schemas[name] = s.object(rt)
+	return name
+}
+
+func (s *openRPCSchemas) object(rt reflect.Type) any {
+	properties := map[string]any{}
+	required := []string{}
+	for _, field := range s.fields(rt) {
+		properties[field.name] = field.schema
+		if field.required {
+			required = append(required, field.name)
+		}
+	}
+	out := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+// fields returns the json fields of a struct in order;
+// embedded structs will be inlined
+func (s *openRPCSchemas) fields(rt reflect.Type) []*openRPCField {
+	out := []*openRPCField{}
+	for i := 0; i < rt.NumField(); i++ {
+		rtf := rt.Field(i)
+		if rtf.Anonymous && rtf.Type.Kind() == reflect.Struct {
+			out = append(out, s.fields(rtf.Type)...)
+			continue
+		}
+		if rtf.PkgPath != "" {
+			// skip private fields
+			continue
+		}
+		name, ok := jsonFieldName(rtf)
+		if !ok {
+			continue
+		}
+		if elem, ok := optionalElem(rtf.Type); ok {
+			// optionals are never required
+			out = append(out, &openRPCField{
+				name:   name,
+				schema: s.schema(elem),
+			})
+			continue
+		}
+		out = append(out, &openRPCField{
+			name:     name,
+			required: rtf.Type.Kind() != reflect.Pointer && !strings.Contains(rtf.Tag.Get("json"), ",omitempty"),
+			schema:   s.schema(rtf.Type),
+		})
+	}
+	return out
+}
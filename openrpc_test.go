@@ -0,0 +1,67 @@
+package jonson
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+type OpenRpcTest struct{}
+
+type openRPCTestGetV1Params struct {
+	Params
+	Uuid   string   `json:"uuid"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+type openRPCTestGetV1Result struct {
+	Uuid      string                    `json:"uuid"`
+	Name      string                    `json:"name"`
+	CreatedAt time.Time                 `json:"createdAt"`
+	Friends   []*openRPCTestGetV1Result `json:"friends,omitempty"`
+	Meta      map[string]int            `json:"meta,omitempty"`
+}
+
+func (o *OpenRpcTest) GetV1(ctx *Context, params *openRPCTestGetV1Params) (*openRPCTestGetV1Result, error) {
+	return nil, nil
+}
+
+func (o *OpenRpcTest) PingV1(ctx *Context) error {
+	return nil
+}
+
+func TestOpenRPCDocument(t *testing.T) {
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.SetOpenRPCInfo(OpenRPCInfo{
+		Title:   "openrpc test",
+		Version: "1.0.0",
+	})
+	mh.RegisterSystem(&OpenRpcTest{})
+	mh.ConfigureMethod("open-rpc-test/get.v1", Summary("Get an entity"), Description("Get returns an entity by uuid"),
+		ExampleCall("by uuid", &openRPCTestGetV1Params{Uuid: "42"}, &openRPCTestGetV1Result{Uuid: "42", Name: "Silvio"}))
+	mh.RegisterError(&Error{Code: 10000, Message: "Entity not found"})
+
+	doc, err := mh.OpenRPCDocument()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := "testdata/openrpc.golden.json"
+	if *updateGolden {
+		if err := os.WriteFile(golden, doc, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(doc, expected) {
+		t.Fatalf("expected document to equal golden file, got:\n%s", doc)
+	}
+}
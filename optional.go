@@ -0,0 +1,176 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// Optional distinguishes between absent, null and present params
+// which is essential for partial updates:
+//
+//	type UpdateV1Params struct {
+//		jonson.Params
+//		// absent: leave unchanged, null: clear, value: set
+//		Nickname jonson.Optional[string] `json:"nickname"`
+//	}
+//
+// The state is derived from the payload's keys: fields whose key is missing
+// within the payload remain absent. Optionals are supported by the json,
+// msgpack and form decoders; form values cannot be null.
+type Optional[T any] struct {
+	value   T
+	present bool
+	null    bool
+}
+
+// NewOptional returns an optional holding the given value
+func NewOptional[T any](v T) Optional[T] {
+	return Optional[T]{value: v, present: true}
+}
+
+// NullOptional returns an optional explicitly set to null
+func NullOptional[T any]() Optional[T] {
+	return Optional[T]{present: true, null: true}
+}
+
+// IsAbsent returns true in case the field was not sent
+func (o Optional[T]) IsAbsent() bool {
+	return !o.present
+}
+
+// IsNull returns true in case the field was explicitly set to null
+func (o Optional[T]) IsNull() bool {
+	return o.present && o.null
+}
+
+// Value returns the value; false is returned
+// in case the field is either absent or null
+func (o Optional[T]) Value() (T, bool) {
+	return o.value, o.present && !o.null
+}
+
+// IsZero reports absent optionals as zero
+func (o Optional[T]) IsZero() bool {
+	return !o.present
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var zero T
+	o.value = zero
+	o.present = true
+	o.null = bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+	if o.null {
+		return nil
+	}
+
+	// apply the same strictness as the params decoder
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(&o.value)
+}
+
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.present || o.null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+func (o *Optional[T]) DecodeMsgpack(dec *msgpack.Decoder) error {
+	var zero T
+	o.value = zero
+	o.present = true
+	code, err := dec.PeekCode()
+	if err != nil {
+		return err
+	}
+	o.null = code == msgpcode.Nil
+	if o.null {
+		return dec.DecodeNil()
+	}
+	return dec.Decode(&o.value)
+}
+
+func (o Optional[T]) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if !o.present || o.null {
+		return enc.EncodeNil()
+	}
+	return enc.Encode(o.value)
+}
+
+// optionalType allows us to detect optionals using reflection
+type optionalType interface {
+	optionalElem() reflect.Type
+	registerMsgpack()
+}
+
+func (o Optional[T]) optionalElem() reflect.Type {
+	return TypeOf[T]()
+}
+
+// registerMsgpack registers the optional's decoder with msgpack;
+// msgpack decodes nil into the zero value before calling DecodeMsgpack
+// which would turn null optionals into absent ones
+func (o Optional[T]) registerMsgpack() {
+	msgpack.Register(Optional[T]{}, nil, func(dec *msgpack.Decoder, v reflect.Value) error {
+		return v.Addr().Interface().(*Optional[T]).DecodeMsgpack(dec)
+	})
+}
+
+var msgpackOptionals = struct {
+	sync.Mutex
+	types map[reflect.Type]bool
+}{types: map[reflect.Type]bool{}}
+
+// registerMsgpackOptionals registers all optionals used within rt with msgpack;
+// registration must happen before msgpack caches the decoders of rt
+func registerMsgpackOptionals(rt reflect.Type) {
+	msgpackOptionals.Lock()
+	defer msgpackOptionals.Unlock()
+	registerMsgpackOptionalsLocked(rt)
+}
+
+func registerMsgpackOptionalsLocked(rt reflect.Type) {
+	if msgpackOptionals.types[rt] {
+		return
+	}
+	msgpackOptionals.types[rt] = true
+
+	if _, ok := optionalElem(rt); ok {
+		reflect.Zero(rt).Interface().(optionalType).registerMsgpack()
+	}
+	switch rt.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		registerMsgpackOptionalsLocked(rt.Elem())
+	case reflect.Struct:
+		for i := 0; i < rt.NumField(); i++ {
+			registerMsgpackOptionalsLocked(rt.Field(i).Type)
+		}
+	}
+}
+
+var typeOptional = reflect.TypeOf((*optionalType)(nil)).Elem()
+
+// optionalSetter allows us to assign optionals using reflection
+type optionalSetter interface {
+	setOptional(v any)
+}
+
+func (o *Optional[T]) setOptional(v any) {
+	o.value = v.(T)
+	o.present = true
+	o.null = false
+}
+
+// optionalElem returns the wrapped type in case rt is an Optional
+func optionalElem(rt reflect.Type) (reflect.Type, bool) {
+	if rt.Kind() != reflect.Struct || !rt.Implements(typeOptional) {
+		return nil, false
+	}
+	return reflect.Zero(rt).Interface().(optionalType).optionalElem(), true
+}
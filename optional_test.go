@@ -0,0 +1,114 @@
+package jonson
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type optionalTestParams struct {
+	Params
+	Nickname Optional[string] `json:"nickname"`
+	Age      Optional[int]    `json:"age"`
+}
+
+func TestOptional(t *testing.T) {
+	decode := func(t *testing.T, payload string) *optionalTestParams {
+		t.Helper()
+		out := &optionalTestParams{}
+		req := &RPCRequest{Params: json.RawMessage(payload)}
+		if err := req.UnmarshalAndValidate(NewDebugSecret(), out, nil); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	t.Run("expect missing keys to be absent", func(t *testing.T) {
+		out := decode(t, `{"age": 5}`)
+		if !out.Nickname.IsAbsent() || out.Nickname.IsNull() {
+			t.Fatal("expected nickname to be absent")
+		}
+		if _, ok := out.Nickname.Value(); ok {
+			t.Fatal("expected absent nickname not to have a value")
+		}
+	})
+
+	t.Run("expect explicit null to be null", func(t *testing.T) {
+		out := decode(t, `{"nickname": null}`)
+		if out.Nickname.IsAbsent() || !out.Nickname.IsNull() {
+			t.Fatal("expected nickname to be null")
+		}
+		if _, ok := out.Nickname.Value(); ok {
+			t.Fatal("expected null nickname not to have a value")
+		}
+	})
+
+	t.Run("expect values to be present", func(t *testing.T) {
+		out := decode(t, `{"nickname": "silvio", "age": 5}`)
+		if v, ok := out.Nickname.Value(); !ok || v != "silvio" {
+			t.Fatalf("expected nickname to be silvio, got: %v", v)
+		}
+		if v, ok := out.Age.Value(); !ok || v != 5 {
+			t.Fatalf("expected age to be 5, got: %v", v)
+		}
+	})
+
+	t.Run("expect invalid values to fail", func(t *testing.T) {
+		req := &RPCRequest{Params: json.RawMessage(`{"age": "five"}`)}
+		if err := req.UnmarshalAndValidate(NewDebugSecret(), &optionalTestParams{}, nil); err == nil {
+			t.Fatal("expected invalid age to fail")
+		}
+	})
+
+	t.Run("expect msgpack payloads to be decoded", func(t *testing.T) {
+		payload, err := msgpack.Marshal(map[string]any{"nickname": "silvio", "age": nil})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := &optionalTestParams{}
+		if err := NewMsgpackDecoder().Decode(payload, out); err != nil {
+			t.Fatal(err)
+		}
+		if v, ok := out.Nickname.Value(); !ok || v != "silvio" {
+			t.Fatalf("expected nickname to be silvio, got: %v", v)
+		}
+		if !out.Age.IsNull() {
+			t.Fatal("expected age to be null")
+		}
+	})
+
+	t.Run("expect form payloads to be decoded", func(t *testing.T) {
+		out := &optionalTestParams{}
+		if err := NewFormDecoder().Decode([]byte("age=5"), out); err != nil {
+			t.Fatal(err)
+		}
+		if v, ok := out.Age.Value(); !ok || v != 5 {
+			t.Fatalf("expected age to be 5, got: %v", v)
+		}
+		if !out.Nickname.IsAbsent() {
+			t.Fatal("expected nickname to be absent")
+		}
+	})
+
+	t.Run("expect optionals to be encoded as msgpack", func(t *testing.T) {
+		b, err := msgpack.Marshal(&optionalTestParams{Nickname: NewOptional("silvio"), Age: NullOptional[int]()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := map[string]any{}
+		if err := msgpack.Unmarshal(b, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out["Nickname"] != "silvio" || out["Age"] != nil {
+			t.Fatalf("unexpected msgpack: %v", out)
+		}
+	})
+
+	t.Run("expect optionals to marshal", func(t *testing.T) {
+		b, _ := json.Marshal(&optionalTestParams{Nickname: NewOptional("silvio"), Age: NullOptional[int]()})
+		if string(b) != `{"nickname":"silvio","age":null}` {
+			t.Fatalf("unexpected json: %s", b)
+		}
+	})
+}
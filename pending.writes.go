@@ -0,0 +1,59 @@
+package jonson
+
+import (
+	"errors"
+)
+
+// PendingWrites may be implemented by transactional values
+// (e.g. a database transaction) to detect forgotten commits:
+// once a request finalizes successfully while the value still has
+// pending writes, the method handler's PendingWritesPolicy applies.
+// In case of errors, values are finalized as usual.
+type PendingWrites interface {
+	// HasPendingWrites returns true in case writes have been issued
+	// which have neither been committed nor rolled back yet
+	HasPendingWrites() bool
+	// Commit commits the pending writes
+	Commit() error
+}
+
+// PendingWritesPolicy defines how values with pending writes are handled
+// once a request finalizes successfully
+type PendingWritesPolicy int
+
+const (
+	// PendingWritesIgnore leaves pending writes to the value's Finalize
+	PendingWritesIgnore PendingWritesPolicy = iota
+	// PendingWritesCommit commits pending writes before finalizing the value
+	PendingWritesCommit
+	// PendingWritesFail fails the request using ErrUncommittedWrites;
+	// the value will be finalized with the error
+	PendingWritesFail
+)
+
+// ErrUncommittedWrites is returned in case a value still had pending writes
+// once the request finalized and the PendingWritesFail policy is set
+var ErrUncommittedWrites = errors.New("pending writes have not been committed")
+
+// SetPendingWritesPolicy sets how values implementing PendingWrites
+// which still have pending writes are handled once a request finalizes
+// successfully; defaults to PendingWritesIgnore.
+func (m *MethodHandler) SetPendingWritesPolicy(policy PendingWritesPolicy) {
+	m.pendingWrites = policy
+}
+
+// checkPendingWrites applies the pending writes policy to the given value;
+// errs are the errors collected so far
+func (c *Context) checkPendingWrites(val any, errs []error) error {
+	if c.pendingWrites == PendingWritesIgnore || len(errs) > 0 {
+		return nil
+	}
+	pw, ok := val.(PendingWrites)
+	if !ok || !pw.HasPendingWrites() {
+		return nil
+	}
+	if c.pendingWrites == PendingWritesCommit {
+		return pw.Commit()
+	}
+	return ErrUncommittedWrites
+}
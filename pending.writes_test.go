@@ -0,0 +1,89 @@
+package jonson
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type pendingWritesTestTx struct {
+	pending    bool
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *pendingWritesTestTx) HasPendingWrites() bool {
+	return tx.pending
+}
+
+func (tx *pendingWritesTestTx) Commit() error {
+	tx.pending = false
+	tx.committed = true
+	return nil
+}
+
+func (tx *pendingWritesTestTx) Finalize(errs []error) error {
+	if tx.pending {
+		tx.pending = false
+		tx.rolledBack = true
+	}
+	return nil
+}
+
+func TestPendingWrites(t *testing.T) {
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *pendingWritesTestTx {
+		return &pendingWritesTestTx{}
+	})
+
+	run := func(policy PendingWritesPolicy, commit bool, fail error) (*pendingWritesTestTx, error) {
+		mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+		mh.SetPendingWritesPolicy(policy)
+		ctx := NewContext(context.Background(), fac, mh)
+		tx := Require[*pendingWritesTestTx](ctx)
+		tx.pending = true
+		if commit {
+			tx.Commit()
+		}
+		return tx, ctx.Finalize(fail)
+	}
+
+	t.Run("expect forgotten commits to be rolled back by default", func(t *testing.T) {
+		tx, err := run(PendingWritesIgnore, false, nil)
+		if err != nil || !tx.rolledBack {
+			t.Fatalf("expected silent rollback, got: %v", err)
+		}
+	})
+
+	t.Run("expect forgotten commits to be committed", func(t *testing.T) {
+		tx, err := run(PendingWritesCommit, false, nil)
+		if err != nil || !tx.committed || tx.rolledBack {
+			t.Fatalf("expected auto commit, got: %v", err)
+		}
+	})
+
+	t.Run("expect forgotten commits to fail the request", func(t *testing.T) {
+		tx, err := run(PendingWritesFail, false, nil)
+		if err == nil || tx.committed || !tx.rolledBack {
+			t.Fatalf("expected rollback with error, got: %v", err)
+		}
+		if rpcErr := AsError(err); len(rpcErr.Data.Details) != 1 || !strings.Contains(rpcErr.Data.Details[0].Data.Debug, ErrUncommittedWrites.Error()) {
+			t.Fatalf("expected uncommitted writes error, got: %v", rpcErr.Data)
+		}
+	})
+
+	t.Run("expect explicit commits to pass", func(t *testing.T) {
+		if _, err := run(PendingWritesFail, true, nil); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("expect failed requests to be rolled back", func(t *testing.T) {
+		for _, policy := range []PendingWritesPolicy{PendingWritesCommit, PendingWritesFail} {
+			tx, err := run(policy, false, ErrInternal)
+			if err != ErrInternal || tx.committed || !tx.rolledBack {
+				t.Fatalf("expected rollback using policy %d, got: %v", policy, err)
+			}
+		}
+	})
+}
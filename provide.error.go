@@ -0,0 +1,102 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// ProvideErrorClass classifies why provisioning a value failed
+type ProvideErrorClass int
+
+const (
+	// ProvideErrorUnknown is used for errors which have not been classified
+	ProvideErrorUnknown ProvideErrorClass = iota
+	// ProvideErrorUnavailable signals an unavailable dependency,
+	// e.g. a database which cannot be reached; usually transient
+	ProvideErrorUnavailable
+	// ProvideErrorTimeout signals the provider ran out of time
+	ProvideErrorTimeout
+	// ProvideErrorConfig signals a misconfiguration which
+	// will not heal without intervention
+	ProvideErrorConfig
+)
+
+func (c ProvideErrorClass) String() string {
+	switch c {
+	case ProvideErrorUnavailable:
+		return "unavailable"
+	case ProvideErrorTimeout:
+		return "timeout"
+	case ProvideErrorConfig:
+		return "config"
+	}
+	return "unknown"
+}
+
+// ProvideError classifies the error of a failing provider;
+// providers panic using the error:
+//
+//	func (p *Provider) NewDB(ctx *jonson.Context) *DB {
+//		db, err := p.pool.Acquire(ctx)
+//		if err != nil {
+//			panic(jonson.NewProvideError(jonson.ProvideErrorUnavailable, err))
+//		}
+//		return db
+//	}
+//
+// The wrapped error is surfaced to the caller as usual, provision failures
+// are reported to the Observer including their class.
+type ProvideError struct {
+	Class ProvideErrorClass
+	Err   error
+}
+
+func NewProvideError(class ProvideErrorClass, err error) *ProvideError {
+	return &ProvideError{
+		Class: class,
+		Err:   err,
+	}
+}
+
+func (e *ProvideError) Error() string {
+	return "provide error (" + e.Class.String() + "): " + e.Err.Error()
+}
+
+func (e *ProvideError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyProvideError returns the class of the given error;
+// deadline errors are classified as ProvideErrorTimeout,
+// other errors not wrapping a ProvideError as ProvideErrorUnknown
+func ClassifyProvideError(err error) ProvideErrorClass {
+	var perr *ProvideError
+	if errors.As(err, &perr) {
+		return perr.Class
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ProvideErrorTimeout
+	}
+	return ProvideErrorUnknown
+}
+
+// observeProvisionFailure reports the failed provisioning to the observer;
+// failures propagating through the Require calls of dependent values
+// are reported for the failing type only
+func (c *Context) observeProvisionFailure(rt reflect.Type, r any) {
+	if c.methodHandler == nil || c.methodHandler.observer == nil {
+		return
+	}
+	c.mu.Lock()
+	// panic values which are not comparable cannot be told apart
+	reported := reflect.TypeOf(r).Comparable() && c.provisionFailure == r
+	c.provisionFailure = r
+	c.mu.Unlock()
+	if reported {
+		return
+	}
+
+	err := getRecoverError(r)
+	c.methodHandler.observer.ObserveProvisionFailure(rt, ClassifyProvideError(err), err)
+}
@@ -0,0 +1,117 @@
+package jonson
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type provideErrorTestDB struct{}
+type provideErrorTestRepo struct{}
+type provideErrorTestConfig struct{}
+type provideErrorTestPlain struct{}
+
+type provideErrorTestProvider struct{}
+
+func (p *provideErrorTestProvider) NewProvideErrorTestDB(ctx *Context) *provideErrorTestDB {
+	panic(NewProvideError(ProvideErrorUnavailable, ErrServerMethodNotAllowed))
+}
+
+func (p *provideErrorTestProvider) NewProvideErrorTestRepo(ctx *Context) *provideErrorTestRepo {
+	Require[*provideErrorTestDB](ctx)
+	return &provideErrorTestRepo{}
+}
+
+func (p *provideErrorTestProvider) NewProvideErrorTestConfig(ctx *Context) *provideErrorTestConfig {
+	panic(NewProvideError(ProvideErrorConfig, errors.New("missing dsn")))
+}
+
+func (p *provideErrorTestProvider) NewProvideErrorTestPlain(ctx *Context) *provideErrorTestPlain {
+	panic(errors.New("plain"))
+}
+
+type provideErrorTestObserver struct {
+	NopObserver
+	failures []string
+}
+
+func (o *provideErrorTestObserver) ObserveProvisionFailure(rt reflect.Type, class ProvideErrorClass, err error) {
+	o.failures = append(o.failures, fmt.Sprintf("%s:%s", rt, class))
+}
+
+type ProvideErrorTest struct{}
+
+func (p *ProvideErrorTest) GetV1(ctx *Context, repo *provideErrorTestRepo) error {
+	return nil
+}
+
+func TestProvideError(t *testing.T) {
+	fac := NewFactory()
+	fac.RegisterProvider(&provideErrorTestProvider{})
+
+	setup := func() (*MethodHandler, *provideErrorTestObserver) {
+		mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+		observer := &provideErrorTestObserver{}
+		mh.SetObserver(observer)
+		return mh, observer
+	}
+
+	tests := []struct {
+		name     string
+		rt       reflect.Type
+		expected string
+	}{
+		{"unavailable", TypeOf[*provideErrorTestDB](), "*jonson.provideErrorTestDB:unavailable"},
+		{"config", TypeOf[*provideErrorTestConfig](), "*jonson.provideErrorTestConfig:config"},
+		{"unknown", TypeOf[*provideErrorTestPlain](), "*jonson.provideErrorTestPlain:unknown"},
+	}
+	for _, tt := range tests {
+		t.Run("expect "+tt.name+" classification to be recorded", func(t *testing.T) {
+			mh, observer := setup()
+			ctx := NewContext(context.Background(), fac, mh)
+			if _, err := ctx.ProvisionAll([]reflect.Type{tt.rt}); err == nil {
+				t.Fatal("expected provisioning to fail")
+			}
+			if len(observer.failures) != 1 || observer.failures[0] != tt.expected {
+				t.Fatalf("expected %s, got: %v", tt.expected, observer.failures)
+			}
+		})
+	}
+
+	t.Run("expect failures of dependencies to be recorded for the failing type only", func(t *testing.T) {
+		mh, observer := setup()
+		ctx := NewContext(context.Background(), fac, mh)
+		_, err := ctx.ProvisionAll([]reflect.Type{TypeOf[*provideErrorTestRepo]()})
+		if ClassifyProvideError(err) != ProvideErrorUnavailable {
+			t.Fatalf("expected unavailable classification, got: %v", err)
+		}
+		if len(observer.failures) != 1 || observer.failures[0] != "*jonson.provideErrorTestDB:unavailable" {
+			t.Fatalf("expected db failure only, got: %v", observer.failures)
+		}
+	})
+
+	t.Run("expect wrapped rpc errors to be surfaced", func(t *testing.T) {
+		mh, observer := setup()
+		mh.RegisterSystem(&ProvideErrorTest{})
+		resp := callRPC(t, mh, "provide-error-test/get.v1", nil)
+		rpcErr := &Error{}
+		if err := json.Unmarshal(resp["error"], rpcErr); err != nil {
+			t.Fatal(err)
+		}
+		if rpcErr.Code != ErrServerMethodNotAllowed.Code {
+			t.Fatalf("expected wrapped error to be returned, got: %+v", rpcErr)
+		}
+		if len(observer.failures) != 1 {
+			t.Fatalf("expected a single failure, got: %v", observer.failures)
+		}
+	})
+
+	t.Run("expect deadline errors to be classified as timeout", func(t *testing.T) {
+		if c := ClassifyProvideError(fmt.Errorf("query: %w", context.DeadlineExceeded)); c != ProvideErrorTimeout {
+			t.Fatalf("expected timeout, got: %s", c)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package jonson
+
+import (
+	"log"
+	"reflect"
+	"sync/atomic"
+)
+
+var TypeQueryCounter = reflect.TypeOf((**QueryCounter)(nil)).Elem()
+
+// RequireQueryCounter returns the query counter of the ongoing request
+func RequireQueryCounter(ctx *Context) *QueryCounter {
+	if v := ctx.Require(TypeQueryCounter); v != nil {
+		return v.(*QueryCounter)
+	}
+	return nil
+}
+
+// QueryRecorder needs to be fed by database layers
+// on each issued query. Database wrappers should accept
+// a QueryRecorder instead of the concrete QueryCounter.
+type QueryRecorder interface {
+	RecordQuery()
+}
+
+// QueryCounter counts the queries issued within a single request.
+// It helps to detect N+1 problems: whenever the number of queries
+// exceeds the method handler's query warn threshold, a warning
+// will be logged during finalization.
+type QueryCounter struct {
+	method        string
+	warnThreshold int
+	count         atomic.Int64
+}
+
+var _ QueryRecorder = (*QueryCounter)(nil)
+
+func NewQueryCounter(method string, warnThreshold int) *QueryCounter {
+	return &QueryCounter{
+		method:        method,
+		warnThreshold: warnThreshold,
+	}
+}
+
+// RecordQuery increments the counter by one
+func (q *QueryCounter) RecordQuery() {
+	q.count.Add(1)
+}
+
+// Count returns the number of queries issued so far
+func (q *QueryCounter) Count() int {
+	return int(q.count.Load())
+}
+
+func (q *QueryCounter) Finalize(errs []error) error {
+	if q.warnThreshold > 0 && q.Count() > q.warnThreshold {
+		log.Printf("query counter: %s issued %d queries (threshold: %d)", q.method, q.Count(), q.warnThreshold)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package jonson
+
+import (
+	"testing"
+)
+
+type queryCounterTestDB struct {
+	recorder QueryRecorder
+}
+
+func (q *queryCounterTestDB) Query() {
+	q.recorder.RecordQuery()
+}
+
+type QueryCounterTest struct{}
+
+func (q *QueryCounterTest) QueryV1(ctx *Context, counter *QueryCounter) (int, error) {
+	db := &queryCounterTestDB{recorder: counter}
+	db.Query()
+	db.Query()
+	db.Query()
+	return counter.Count(), nil
+}
+
+func TestQueryCounter(t *testing.T) {
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterSystem(&QueryCounterTest{})
+
+	t.Run("expect counter to reflect issued queries and reset per request", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			resp := callRPC(t, mh, "query-counter-test/query.v1", nil)
+			if string(resp["result"]) != "3" {
+				t.Fatalf("expected 3 queries, got: %s", resp["result"])
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package jonson
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+)
+
+// ErrHijackUnsupported is returned in case the transport
+// cannot expose its underlying connection
+var ErrHijackUnsupported = errors.New("raw conn: transport does not support hijacking")
+
+// RawConn is the hijacked connection of the ongoing http request.
+// Once hijacked, the caller owns the connection: jonson will neither
+// write a response nor close the connection, the handler is responsible
+// for closing Conn once done.
+type RawConn struct {
+	Conn       net.Conn
+	ReadWriter *bufio.ReadWriter
+}
+
+var TypeRawConn = reflect.TypeOf((**RawConn)(nil)).Elem()
+
+// RequireRawConn hijacks the connection of the ongoing request;
+// subsequent calls return the same connection.
+// ErrHijackUnsupported is returned in case the transport
+// (e.g. an established websocket connection) cannot be hijacked.
+// Read the RawConn's documentation on the ownership of the connection.
+func RequireRawConn(ctx *Context) (*RawConn, error) {
+	if v, ok := ctx.lookup(TypeRawConn); ok {
+		return v.(*RawConn), nil
+	}
+
+	w, _ := ctx.lookup(TypeHTTPResponseWriter)
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, ErrHijackUnsupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHijackUnsupported, err)
+	}
+
+	raw := &RawConn{
+		Conn:       conn,
+		ReadWriter: rw,
+	}
+	ctx.StoreValue(TypeRawConn, raw)
+	return raw, nil
+}
+
+// hijackTracker keeps track of hijacked connections so
+// http transports do not write responses to hijacked connections
+type hijackTracker struct {
+	http.ResponseWriter
+	hijacked bool
+}
+
+func newHijackTracker(w http.ResponseWriter) *hijackTracker {
+	return &hijackTracker{
+		ResponseWriter: w,
+	}
+}
+
+func (h *hijackTracker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := h.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, ErrHijackUnsupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		h.hijacked = true
+	}
+	return conn, rw, err
+}
+
+func (h *hijackTracker) Flush() {
+	if f, ok := h.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to access the underlying writer
+func (h *hijackTracker) Unwrap() http.ResponseWriter {
+	return h.ResponseWriter
+}
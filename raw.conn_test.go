@@ -0,0 +1,76 @@
+package jonson
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// rawConnTestWriter is a transport which supports hijacking
+type rawConnTestWriter struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (r *rawConnTestWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.conn, bufio.NewReadWriter(bufio.NewReader(r.conn), bufio.NewWriter(r.conn)), nil
+}
+
+type RawConnTest struct{}
+
+func (r *RawConnTest) UpgradeV1(ctx *Context) error {
+	raw, err := RequireRawConn(ctx)
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer raw.Conn.Close()
+		raw.ReadWriter.WriteString("custom protocol")
+		raw.ReadWriter.Flush()
+	}()
+	return nil
+}
+
+func TestRequireRawConn(t *testing.T) {
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterSystem(&RawConnTest{})
+	handler := NewHttpMethodHandler(mh)
+
+	t.Run("expect hijacked connection to be owned by the method", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		w := &rawConnTestWriter{ResponseRecorder: httptest.NewRecorder(), conn: server}
+
+		handler.Handle(w, httptest.NewRequest(http.MethodGet, "/raw-conn-test/upgrade.v1", nil))
+
+		buf := make([]byte, len("custom protocol"))
+		if _, err := client.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != "custom protocol" {
+			t.Fatalf("expected custom protocol, got: %s", buf)
+		}
+		if w.Body.Len() != 0 {
+			t.Fatalf("expected no response to be written, got: %s", w.Body.String())
+		}
+	})
+
+	t.Run("expect ErrHijackUnsupported for transports not supporting hijacking", func(t *testing.T) {
+		w := &struct{ http.ResponseWriter }{httptest.NewRecorder()}
+		ctx := NewContext(httptest.NewRequest(http.MethodGet, "/", nil).Context(), mh.provider, mh)
+		ctx.StoreValue(TypeHTTPResponseWriter, w)
+		if _, err := RequireRawConn(ctx); !errors.Is(err, ErrHijackUnsupported) {
+			t.Fatalf("expected ErrHijackUnsupported, got: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.Handle(recorder, httptest.NewRequest(http.MethodGet, "/raw-conn-test/upgrade.v1", nil))
+		if recorder.Code != http.StatusInternalServerError || !bytes.Contains(recorder.Body.Bytes(), []byte(`"code":-32603`)) {
+			t.Fatalf("expected internal error, got: %d %s", recorder.Code, recorder.Body.String())
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package jonson
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// RequestIDHeader is the http header used to pass
+// request ids from upstream services
+const RequestIDHeader = "X-Request-Id"
+
+// NewRequestID returns a new random request id
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// SetRequestIDFunc sets the function generating request ids;
+// defaults to NewRequestID. Http requests carrying the X-Request-Id
+// header will use the header's value instead.
+func (m *MethodHandler) SetRequestIDFunc(fn func() string) {
+	m.requestID = fn
+}
+
+// newRequestID returns the request id of the given request
+func (m *MethodHandler) newRequestID(r *http.Request, ws *WSClient) string {
+	if ws == nil && r != nil {
+		if id := r.Header.Get(RequestIDHeader); id != "" {
+			return id
+		}
+	}
+	return m.requestID()
+}
+
+// requestIDOf returns the request id stored within the context
+// without provisioning any values
+func requestIDOf(ctx *Context) string {
+	if v, ok := ctx.lookup(TypeRPCMeta); ok {
+		return v.(*RPCMeta).RequestID
+	}
+	return ""
+}
+
+// AccessLogEntry describes a single processed rpc call
+type AccessLogEntry struct {
+	RequestID string
+	Method    string
+	Duration  time.Duration
+	// Err is set in case the call failed
+	Err error
+}
+
+// AccessLogger writes access log entries
+type AccessLogger interface {
+	LogAccess(entry *AccessLogEntry)
+}
+
+// AccessLoggerFunc allows us to use functions as AccessLogger
+type AccessLoggerFunc func(entry *AccessLogEntry)
+
+func (f AccessLoggerFunc) LogAccess(entry *AccessLogEntry) {
+	f(entry)
+}
+
+// SetAccessLogger sets the logger receiving an entry per processed rpc call;
+// disabled by default.
+func (m *MethodHandler) SetAccessLogger(logger AccessLogger) {
+	m.accessLogger = logger
+}
@@ -0,0 +1,91 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// ResponseBatcher coalesces responses of a connection into a single
+// JSON-RPC batch in order to reduce the number of physical writes under high load;
+// meant for collecting clients which match responses by their id:
+//
+//	options := jonson.NewWebsocketOptions()
+//	options.ResponseBatcher = &jonson.ResponseBatcher{
+//		Window:   2 * time.Millisecond,
+//		MaxCount: 32,
+//	}
+//
+// Once a response is ready, the batcher waits for further responses
+// up to Window; the batch is written as soon as MaxCount responses are collected.
+// Responses are therefore delayed by Window at most.
+// Collected batch responses are flattened into the batch;
+// in case a single response has been collected, it will be written as is.
+// Notifications are never batched, they are written on their own.
+type ResponseBatcher struct {
+	// Window is the maximum time to wait for further responses
+	Window time.Duration
+	// MaxCount is the maximum number of responses within a batch
+	MaxCount int
+}
+
+// collect collects further messages from next until the window passed
+// or the batch is full and returns the message to be written;
+// next might be closed in the meantime
+func (b *ResponseBatcher) collect(first []byte, next <-chan []byte) []byte {
+	batch := newResponseBatch()
+	batch.add(first)
+	if batch.full(b.MaxCount) || b.Window <= 0 {
+		return batch.bytes(first)
+	}
+
+	timer := time.NewTimer(b.Window)
+	defer timer.Stop()
+	for !batch.full(b.MaxCount) {
+		select {
+		case msg, ok := <-next:
+			if !ok {
+				return batch.bytes(first)
+			}
+			batch.add(msg)
+		case <-timer.C:
+			return batch.bytes(first)
+		}
+	}
+	return batch.bytes(first)
+}
+
+type responseBatch struct {
+	messages []json.RawMessage
+}
+
+func newResponseBatch() *responseBatch {
+	return &responseBatch{}
+}
+
+// add adds the message to the batch;
+// batch responses will be flattened
+func (r *responseBatch) add(msg []byte) {
+	if trimmed := bytes.TrimSpace(msg); len(trimmed) > 0 && trimmed[0] == '[' {
+		batch := []json.RawMessage{}
+		if err := json.Unmarshal(trimmed, &batch); err == nil {
+			r.messages = append(r.messages, batch...)
+			return
+		}
+	}
+	r.messages = append(r.messages, msg)
+}
+
+func (r *responseBatch) full(maxCount int) bool {
+	return maxCount > 0 && len(r.messages) >= maxCount
+}
+
+// bytes returns the encoded batch; the first message is returned
+// as is in case nothing else has been collected
+func (r *responseBatch) bytes(first []byte) []byte {
+	if len(r.messages) <= 1 {
+		return first
+	}
+	b, _ := json.Marshal(r.messages)
+	return b
+}
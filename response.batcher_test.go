@@ -0,0 +1,137 @@
+package jonson
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type ResponseBatcherTest struct{}
+
+func (r *ResponseBatcherTest) NotifyV1(ctx *Context) (string, error) {
+	if err := RequireWSClient(ctx).SendNotification(NewRPCNotification("account/updated.v1", nil)); err != nil {
+		return "", err
+	}
+	return "notified", nil
+}
+
+func TestResponseBatcher(t *testing.T) {
+	setup := func(t *testing.T, batcher *ResponseBatcher) *websocket.Conn {
+		t.Helper()
+		mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+		mh.RegisterSystem(&MethodHandlerTest{})
+		mh.RegisterSystem(&ResponseBatcherTest{})
+
+		options := NewWebsocketOptions()
+		options.ResponseBatcher = batcher
+		server := httptest.NewServer(NewServer(NewWebsocketHandler(mh, "/ws", options)))
+		t.Cleanup(server.Close)
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	callMethod := func(t *testing.T, conn *websocket.Conn, id int, method string, params any) {
+		t.Helper()
+		err := conn.WriteJSON(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      id,
+			"method":  method,
+			"params":  params,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	call := func(t *testing.T, conn *websocket.Conn, id int) {
+		t.Helper()
+		callMethod(t, conn, id, "method-handler-test/echo.v1", map[string]any{"name": "Silvio"})
+	}
+
+	read := func(t *testing.T, conn *websocket.Conn) []byte {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	ids := func(responses []*RPCResultResponse) string {
+		out := []string{}
+		for _, resp := range responses {
+			out = append(out, string(resp.ID))
+		}
+		sort.Strings(out)
+		return strings.Join(out, ",")
+	}
+
+	t.Run("expect responses to be flushed together once the count threshold is hit", func(t *testing.T) {
+		conn := setup(t, &ResponseBatcher{Window: time.Minute, MaxCount: 3})
+		for id := 1; id <= 3; id++ {
+			call(t, conn, id)
+		}
+
+		// the read deadline is way shorter than the window
+		responses := []*RPCResultResponse{}
+		if err := json.Unmarshal(read(t, conn), &responses); err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(responses); got != "1,2,3" {
+			t.Fatalf("expected responses 1,2,3 within a single batch, got: %s", got)
+		}
+	})
+
+	t.Run("expect notifications not to be batched along with responses", func(t *testing.T) {
+		conn := setup(t, &ResponseBatcher{Window: time.Minute, MaxCount: 3})
+		callMethod(t, conn, 1, "response-batcher-test/notify.v1", nil)
+		call(t, conn, 2)
+		call(t, conn, 3)
+
+		var (
+			notifications int
+			responses     []*RPCResultResponse
+		)
+		for i := 0; i < 2; i++ {
+			msg := read(t, conn)
+			if msg[0] == '[' {
+				if err := json.Unmarshal(msg, &responses); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			notification := &RPCNotification{}
+			if err := json.Unmarshal(msg, notification); err != nil || notification.Method != "account/updated.v1" {
+				t.Fatalf("expected notification, got: %s", msg)
+			}
+			notifications++
+		}
+		if notifications != 1 || ids(responses) != "1,2,3" {
+			t.Fatalf("expected a notification and a batch of responses, got %d notifications and responses %s", notifications, ids(responses))
+		}
+	})
+
+	t.Run("expect single response to be flushed as is after the window", func(t *testing.T) {
+		conn := setup(t, &ResponseBatcher{Window: 10 * time.Millisecond, MaxCount: 10})
+		call(t, conn, 1)
+
+		resp := &RPCResultResponse{}
+		if err := json.Unmarshal(read(t, conn), resp); err != nil {
+			t.Fatal(err)
+		}
+		if string(resp.ID) != "1" {
+			t.Fatalf("expected response 1, got: %s", resp.ID)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package jonson
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+)
+
+// SetStrictResults enables the strict result mode meant for development and staging:
+// results are validated against the method's result type before being encoded.
+// Required fields (neither pointers, optionals nor tagged omitempty) must not encode
+// to null and Validate<Field> methods of the result are executed.
+// Violations are logged and the request fails with ErrInternal. Disabled by default.
+func (m *MethodHandler) SetStrictResults(strict bool) {
+	m.strictResults = strict
+}
+
+// checkResult validates the method's result in strict result mode
+func (m *MethodHandler) checkResult(method string, res any) error {
+	if !m.strictResults || res == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(res)
+	violations := resultViolations(rv, "")
+
+	if rv := reflect.Indirect(rv); rv.IsValid() && rv.Kind() == reflect.Struct && !isOpaqueResult(rv.Type()) {
+		// causes remain plain text here, the message is encoded as a whole below
+		if err := Validate(NewDebugSecret(), res); err != nil {
+			for _, d := range AsError(err).Data.Details {
+				if d.Data == nil {
+					continue
+				}
+				cause := d.Message
+				if d.Data.Debug != "" {
+					cause = d.Data.Debug
+				}
+				violations = append(violations, resultPath(d.Data.Path)+": "+cause)
+			}
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("method %s returned an invalid result: %s", method, strings.Join(violations, "; "))
+	log.Print("method handler: STRICT RESULTS: ", msg)
+	return ErrInternal.CloneWithData(&ErrorData{
+		Debug: m.errorEncoder.Encode(msg),
+	})
+}
+
+// isOpaqueResult returns true for types we do not look into
+// since they take care of their own encoding
+func isOpaqueResult(rt reflect.Type) bool {
+	if _, ok := optionalElem(rt); ok {
+		return true
+	}
+	return rt == typeTime || rt == typeHTMLResult || rt == typeBinaryResult
+}
+
+// resultViolations walks the value the same way the OpenRPC schema
+// describes it and returns all required fields encoding to null
+func resultViolations(rv reflect.Value, path string) []string {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	var out []string
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		for i := 0; i < rv.Len(); i++ {
+			out = append(out, resultViolations(rv.Index(i), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			out = append(out, resultViolations(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()))...)
+		}
+	case reflect.Struct:
+		if isOpaqueResult(rv.Type()) {
+			return nil
+		}
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			rtf := rt.Field(i)
+			if rtf.Anonymous && rtf.Type.Kind() == reflect.Struct {
+				out = append(out, resultViolations(rv.Field(i), path)...)
+				continue
+			}
+			if rtf.PkgPath != "" {
+				continue
+			}
+			name, ok := jsonFieldName(rtf)
+			if !ok {
+				continue
+			}
+			if _, ok := optionalElem(rtf.Type); ok {
+				continue
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			fv := rv.Field(i)
+			required := rtf.Type.Kind() != reflect.Pointer && !strings.Contains(rtf.Tag.Get("json"), ",omitempty")
+			if required && isNullValue(fv) {
+				out = append(out, fieldPath+": missing required field")
+				continue
+			}
+			out = append(out, resultViolations(fv, fieldPath)...)
+		}
+	}
+	return out
+}
+
+// isNullValue returns true in case the value encodes to null
+func isNullValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// resultPath formats the validation path, e.g. friends[0].name
+func resultPath(path []any) string {
+	out := ""
+	for _, p := range path {
+		if i, ok := p.(int); ok {
+			out += fmt.Sprintf("[%d]", i)
+			continue
+		}
+		if out != "" {
+			out += "."
+		}
+		out += fmt.Sprint(p)
+	}
+	return out
+}
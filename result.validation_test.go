@@ -0,0 +1,131 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+type resultValidationTestParams struct {
+	Params
+	Broken bool `json:"broken"`
+}
+
+type resultValidationTestFriend struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+type resultValidationTestResult struct {
+	Name     string                        `json:"name"`
+	Friends  []*resultValidationTestFriend `json:"friends"`
+	Nickname *string                       `json:"nickname"`
+	Labels   []string                      `json:"labels,omitempty"`
+}
+
+func (r *resultValidationTestResult) ValidateName() error {
+	if r.Name == "" {
+		return errors.New("must not be empty")
+	}
+	return nil
+}
+
+type ResultValidationTest struct{}
+
+func (r *ResultValidationTest) GetV1(ctx *Context, params *resultValidationTestParams) (*resultValidationTestResult, error) {
+	if params.Broken {
+		// the friend's tags are missing
+		return &resultValidationTestResult{
+			Name:    "Silvio",
+			Friends: []*resultValidationTestFriend{{Name: "Mario"}},
+		}, nil
+	}
+	return &resultValidationTestResult{
+		Name:    "Silvio",
+		Friends: []*resultValidationTestFriend{{Name: "Mario", Tags: []string{}}},
+	}, nil
+}
+
+func (r *ResultValidationTest) EmptyV1(ctx *Context, params *resultValidationTestParams) (*resultValidationTestResult, error) {
+	return &resultValidationTestResult{Friends: []*resultValidationTestFriend{}}, nil
+}
+
+func TestStrictResults(t *testing.T) {
+	logs := &bytes.Buffer{}
+	log.SetOutput(logs)
+	defer log.SetOutput(os.Stderr)
+
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterMethod(&MethodDefinition{
+		System:      "result-validation-test",
+		Method:      "get",
+		Version:     1,
+		HandlerFunc: (&ResultValidationTest{}).GetV1,
+	})
+	mh.RegisterMethod(&MethodDefinition{
+		System:      "result-validation-test",
+		Method:      "empty",
+		Version:     1,
+		HandlerFunc: (&ResultValidationTest{}).EmptyV1,
+	})
+
+	rpcError := func(t *testing.T, resp map[string]json.RawMessage) *Error {
+		t.Helper()
+		if resp["error"] == nil {
+			return nil
+		}
+		out := &Error{}
+		if err := json.Unmarshal(resp["error"], out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	t.Run("expect invalid results to pass by default", func(t *testing.T) {
+		resp := callRPC(t, mh, "result-validation-test/get.v1", map[string]any{"broken": true})
+		if err := rpcError(t, resp); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+	})
+
+	mh.SetStrictResults(true)
+
+	t.Run("expect valid results to pass in strict mode", func(t *testing.T) {
+		logs.Reset()
+		resp := callRPC(t, mh, "result-validation-test/get.v1", map[string]any{})
+		if err := rpcError(t, resp); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		if strings.Contains(logs.String(), "STRICT RESULTS") {
+			t.Fatalf("expected nothing to be logged, got: %s", logs.String())
+		}
+	})
+
+	t.Run("expect missing required fields to fail in strict mode", func(t *testing.T) {
+		logs.Reset()
+		err := rpcError(t, callRPC(t, mh, "result-validation-test/get.v1", map[string]any{"broken": true}))
+		if err == nil || err.Code != ErrInternal.Code {
+			t.Fatalf("expected internal error, got: %v", err)
+		}
+		if err.Data == nil || !strings.Contains(err.Data.Debug, "friends[0].tags: missing required field") {
+			t.Fatalf("expected debug to name the missing field, got: %+v", err.Data)
+		}
+		if !strings.Contains(logs.String(), "STRICT RESULTS") {
+			t.Fatalf("expected violation to be logged, got: %s", logs.String())
+		}
+	})
+
+	t.Run("expect result validators to be executed in strict mode", func(t *testing.T) {
+		err := rpcError(t, callRPC(t, mh, "result-validation-test/empty.v1", map[string]any{}))
+		if err == nil || err.Code != ErrInternal.Code {
+			t.Fatalf("expected internal error, got: %v", err)
+		}
+		if err.Data == nil || !strings.Contains(err.Data.Debug, "name: must not be empty") {
+			t.Fatalf("expected debug to name the invalid field, got: %+v", err.Data)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// TransientError may be implemented by errors which are temporary,
+// e.g. caused by a flaky downstream service
+type TransientError interface {
+	Transient() bool
+}
+
+// RetryOptions configure retries of idempotent methods
+type RetryOptions struct {
+	// Attempts is the maximum number of attempts, including the first one
+	Attempts int
+	// Backoff is the delay before the second attempt;
+	// the delay doubles for each further attempt
+	Backoff time.Duration
+	// TransientCodes contains error codes which are considered
+	// transient in addition to errors implementing TransientError
+	TransientCodes []int
+}
+
+// SetRetry enables retries of methods marked as Idempotent:
+// whenever such a method fails with a transient error, the method will be
+// called again using a fresh context. Each attempt's context is finalized
+// before the next attempt starts. Non-idempotent methods are never retried.
+// Disabled by default.
+func (m *MethodHandler) SetRetry(options *RetryOptions) {
+	m.retry = options
+}
+
+// IsTransient returns true in case err is a transient error
+// according to the retry options
+func (r *RetryOptions) IsTransient(err error) bool {
+	var transient TransientError
+	if errors.As(err, &transient) {
+		return transient.Transient()
+	}
+	if r == nil {
+		return false
+	}
+	code := AsError(err).Code
+	for _, v := range r.TransientCodes {
+		if v == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retry returns true in case the failed attempt should be retried
+func (r *RetryOptions) retry(attempt int, err error) bool {
+	return r != nil && attempt < r.Attempts && r.IsTransient(err)
+}
+
+// wait waits for the backoff of the given attempt;
+// false is returned in case ctx is done before
+func (r *RetryOptions) wait(ctx context.Context, attempt int) bool {
+	backoff := r.Backoff << (attempt - 1)
+	if backoff <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
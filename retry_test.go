@@ -0,0 +1,120 @@
+package jonson
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type retryTestError struct{}
+
+func (r *retryTestError) Error() string {
+	return "downstream unavailable"
+}
+
+func (r *retryTestError) Transient() bool {
+	return true
+}
+
+type retryTestTx struct {
+	finalized *[]bool
+}
+
+func (r *retryTestTx) Finalize(errs []error) error {
+	*r.finalized = append(*r.finalized, len(errs) > 0)
+	return nil
+}
+
+type RetryTest struct {
+	calls int
+}
+
+func (r *RetryTest) FlakyV1(ctx *Context, tx *retryTestTx) (int, error) {
+	r.calls++
+	if r.calls == 1 {
+		return 0, &retryTestError{}
+	}
+	return r.calls, nil
+}
+
+func (r *RetryTest) WarnV1(ctx *Context) (int, error) {
+	r.calls++
+	RequireWarnings(ctx).Add("attempt", fmt.Sprintf("attempt %d", r.calls))
+	if r.calls == 1 {
+		return 0, &retryTestError{}
+	}
+	return r.calls, nil
+}
+
+func TestRetry(t *testing.T) {
+	setup := func(opts ...MethodOption) (*MethodHandler, *RetryTest, *[]bool) {
+		finalized := &[]bool{}
+		fac := NewFactory()
+		fac.RegisterProviderFunc(func(ctx *Context) *retryTestTx {
+			return &retryTestTx{finalized: finalized}
+		})
+		system := &RetryTest{}
+		mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+		mh.RegisterMethod(&MethodDefinition{
+			System:      "retry-test",
+			Method:      "flaky",
+			Version:     1,
+			HandlerFunc: system.FlakyV1,
+		}, opts...)
+		mh.RegisterMethod(&MethodDefinition{
+			System:      "retry-test",
+			Method:      "warn",
+			Version:     1,
+			HandlerFunc: system.WarnV1,
+		}, opts...)
+		mh.SetRetry(&RetryOptions{Attempts: 3, Backoff: time.Millisecond})
+		return mh, system, finalized
+	}
+
+	t.Run("expect idempotent method to be retried on transient error", func(t *testing.T) {
+		mh, system, finalized := setup(Idempotent())
+		resp := callRPC(t, mh, "retry-test/flaky.v1", nil)
+		if string(resp["result"]) != "2" {
+			t.Fatalf("expected second attempt to succeed, got: %s %s", resp["result"], resp["error"])
+		}
+		if system.calls != 2 {
+			t.Fatalf("expected 2 calls, got: %d", system.calls)
+		}
+		// the failed attempt's context has been finalized with errors
+		if len(*finalized) != 2 || !(*finalized)[0] || (*finalized)[1] {
+			t.Fatalf("expected each attempt to be finalized, got: %v", *finalized)
+		}
+	})
+
+	t.Run("expect only warnings of the successful attempt to be returned", func(t *testing.T) {
+		mh, _, _ := setup(Idempotent())
+		resp := callRPC(t, mh, "retry-test/warn.v1", nil)
+		warnings := []*Warning{}
+		if err := json.Unmarshal(resp["warnings"], &warnings); err != nil {
+			t.Fatalf("expected warnings, got: %s", resp["warnings"])
+		}
+		if len(warnings) != 1 || warnings[0].Message != "attempt 2" {
+			t.Fatalf("expected the second attempt's warning only, got: %s", resp["warnings"])
+		}
+	})
+
+	t.Run("expect non-idempotent method not to be retried", func(t *testing.T) {
+		mh, system, _ := setup()
+		resp := callRPC(t, mh, "retry-test/flaky.v1", nil)
+		rpcErr := &Error{}
+		if err := json.Unmarshal(resp["error"], rpcErr); err != nil || rpcErr.Code != ErrInternal.Code {
+			t.Fatalf("expected internal error, got: %s", resp["error"])
+		}
+		if system.calls != 1 {
+			t.Fatalf("expected a single call, got: %d", system.calls)
+		}
+	})
+
+	t.Run("expect transient codes to be classified", func(t *testing.T) {
+		options := &RetryOptions{TransientCodes: []int{ErrTimeout.Code}}
+		if !options.IsTransient(ErrTimeout) || options.IsTransient(ErrUnauthorized) {
+			t.Fatal("expected only ErrTimeout to be transient")
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package jonson
+
+import (
+	"log"
+)
+
+// Dispatcher executes remote procedure calls.
+// The MethodHandler dispatches calls to its registered methods;
+// proxies may forward calls to remote nodes.
+type Dispatcher interface {
+	Dispatch(ctx *Context, rpcRequest *RPCRequest, bindata []byte) (any, error)
+}
+
+// Router decides which dispatcher executes a call,
+// e.g. to direct calls to partition specific handlers
+// or to forward calls to remote nodes.
+type Router interface {
+	Route(ctx *Context, method string) (Dispatcher, error)
+}
+
+// RouterFunc allows us to use a function as Router
+type RouterFunc func(ctx *Context, method string) (Dispatcher, error)
+
+func (f RouterFunc) Route(ctx *Context, method string) (Dispatcher, error) {
+	return f(ctx, method)
+}
+
+// SetRouter sets the router consulted for each call;
+// return the method handler itself from the router to dispatch calls locally.
+// Dispatchers receive the context created by this method handler.
+// By default, all calls are dispatched locally.
+func (m *MethodHandler) SetRouter(router Router) {
+	m.router = router
+}
+
+var _ Dispatcher = (*MethodHandler)(nil)
+
+// Dispatch calls a method registered within the method handler
+func (m *MethodHandler) Dispatch(ctx *Context, rpcRequest *RPCRequest, bindata []byte) (any, error) {
+	return m.dispatch(ctx, rpcRequest, bindata)
+}
+
+// route dispatches the call using the router
+func (m *MethodHandler) route(ctx *Context, rpcRequest *RPCRequest, bindata []byte) (any, error) {
+	if mnt, method, ok := m.mounted(rpcRequest.Method); ok {
+		return mnt.dispatch(ctx, method, rpcRequest, bindata)
+	}
+	if m.router == nil {
+		return m.dispatch(ctx, rpcRequest, bindata)
+	}
+	dispatcher, err := m.router.Route(ctx, rpcRequest.Method)
+	if err != nil {
+		log.Print("method handler: route error: ", err)
+		return nil, err
+	}
+	if dispatcher == nil {
+		log.Print("method handler: no route found: ", rpcRequest.Method)
+		return nil, ErrMethodNotFound
+	}
+	return dispatcher.Dispatch(ctx, rpcRequest, bindata)
+}
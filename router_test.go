@@ -0,0 +1,53 @@
+package jonson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// routerTestRemote mocks a proxy forwarding calls to a remote node
+type routerTestRemote struct {
+	calls []string
+}
+
+func (r *routerTestRemote) Dispatch(ctx *Context, rpcRequest *RPCRequest, bindata []byte) (any, error) {
+	r.calls = append(r.calls, rpcRequest.Method)
+	return "remote:" + string(rpcRequest.Params), nil
+}
+
+func TestRouter(t *testing.T) {
+	remote := &routerTestRemote{}
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.RegisterSystem(&MethodHandlerTest{})
+	mh.SetRouter(RouterFunc(func(ctx *Context, method string) (Dispatcher, error) {
+		if strings.HasPrefix(method, "tenant-b/") {
+			return remote, nil
+		}
+		return mh, nil
+	}))
+
+	t.Run("expect local methods to be dispatched locally", func(t *testing.T) {
+		resp := callRPC(t, mh, "method-handler-test/echo.v1", map[string]any{"name": "Silvio"})
+		if string(resp["result"]) != `"Silvio"` {
+			t.Fatalf("expected local result, got: %s %s", resp["result"], resp["error"])
+		}
+		if len(remote.calls) != 0 {
+			t.Fatalf("expected no remote calls, got: %v", remote.calls)
+		}
+	})
+
+	t.Run("expect routed methods to be forwarded to remote", func(t *testing.T) {
+		resp := callRPC(t, mh, "tenant-b/echo.v1", map[string]any{"name": "Silvio"})
+		var result string
+		if err := json.Unmarshal(resp["result"], &result); err != nil {
+			t.Fatalf("expected remote result, got: %s", resp["error"])
+		}
+		if result != `remote:{"name":"Silvio"}` {
+			t.Fatalf("expected remote result, got: %s", result)
+		}
+		if len(remote.calls) != 1 || remote.calls[0] != "tenant-b/echo.v1" {
+			t.Fatalf("expected a single remote call, got: %v", remote.calls)
+		}
+	})
+}
@@ -1,28 +1,61 @@
 package jonson
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"reflect"
+	"time"
 )
 
 // RPC internal errors
 var (
-	ErrParse                  = &Error{Code: -32700, Message: "Parse error"}
-	ErrMethodNotFound         = &Error{Code: -32601, Message: "Method not found"}
-	ErrInvalidParams          = &Error{Code: -32602, Message: "Invalid params"}
-	ErrInternal               = &Error{Code: -32603, Message: "Internal error"}
-	ErrServerMethodNotAllowed = &Error{Code: -32000, Message: "Server error: method not allowed"}
-	ErrUnauthorized           = &Error{Code: -32001, Message: "Server error: unauthorized"}
-	ErrUnauthenticated        = &Error{Code: -32002, Message: "Server error: unauthenticated"}
+	ErrParse                   = &Error{Code: -32700, Message: "Parse error"}
+	ErrMethodNotFound          = &Error{Code: -32601, Message: "Method not found"}
+	ErrInvalidParams           = &Error{Code: -32602, Message: "Invalid params"}
+	ErrInternal                = &Error{Code: -32603, Message: "Internal error"}
+	ErrServerMethodNotAllowed  = &Error{Code: -32000, Message: "Server error: method not allowed"}
+	ErrUnauthorized            = &Error{Code: -32001, Message: "Server error: unauthorized"}
+	ErrUnauthenticated         = &Error{Code: -32002, Message: "Server error: unauthenticated"}
+	ErrTimeout                 = &Error{Code: -32003, Message: "Server error: timeout"}
+	ErrDraining                = &Error{Code: -32004, Message: "Server error: draining"}
+	ErrUnsupportedContentType  = &Error{Code: -32005, Message: "Server error: unsupported content type"}
+	ErrRequestIncomplete       = &Error{Code: -32006, Message: "Server error: request incomplete"}
+	ErrClientGone              = &Error{Code: -32007, Message: "Server error: client gone"}
+	ErrBatchAborted            = &Error{Code: -32008, Message: "Server error: batch aborted"}
+	ErrBinaryResultUnsupported = &Error{Code: -32009, Message: "Server error: binary results are only supported by http methods"}
 )
 
+// rpcErrors contains all errors predefined by jonson;
+// those errors are registered implicitly
+var rpcErrors = []*Error{
+	ErrParse,
+	ErrMethodNotFound,
+	ErrInvalidParams,
+	ErrInternal,
+	ErrServerMethodNotAllowed,
+	ErrUnauthorized,
+	ErrUnauthenticated,
+	ErrTimeout,
+	ErrDraining,
+	ErrUnsupportedContentType,
+	ErrRequestIncomplete,
+	ErrClientGone,
+	ErrBatchAborted,
+	ErrBinaryResultUnsupported,
+}
+
 // RPCRequest object
 type RPCRequest struct {
 	Version string          `json:"jsonrpc"`
 	ID      json.RawMessage `json:"id"`
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params"`
+
+	// contentType defines the content type of params;
+	// json is assumed in case it's empty
+	contentType string
+	// binary is set in case the transport supports binary results
+	binary bool
 }
 
 // RPCNotification object
@@ -47,15 +80,35 @@ func NewRPCNotification(method string, payload any) *RPCNotification {
 	}
 }
 
-// UnmarshalAndValidate fills the given interface with the supplied params
+// UnmarshalAndValidate fills the given interface with the supplied json params
 func (r *RPCRequest) UnmarshalAndValidate(errEncoder Secret, out any, bindata []byte) error {
-	dec := json.NewDecoder(bytes.NewReader([]byte(r.Params)))
-	dec.DisallowUnknownFields()
-	dec.UseNumber()
-	if err := dec.Decode(out); err != nil {
-		return ErrInvalidParams.CloneWithData(&ErrorData{
+	return r.DecodeAndValidate(NewJSONDecoder(), errEncoder, out, bindata)
+}
+
+// DecodeAndValidate fills the given interface with the supplied params
+// using the given decoder; the params' Validate<Field> methods are executed
+// and failing params are rejected with ErrInvalidParams
+func (r *RPCRequest) DecodeAndValidate(decoder PayloadDecoder, errEncoder Secret, out any, bindata []byte) error {
+	if err := r.decode(decoder, errEncoder, out, bindata); err != nil {
+		return err
+	}
+
+	// start validation process
+	return Validate(errEncoder, out)
+}
+
+// decode fills the given interface with the supplied params
+// without validating them
+func (r *RPCRequest) decode(decoder PayloadDecoder, errEncoder Secret, out any, bindata []byte) error {
+	if err := decoder.Decode([]byte(r.Params), out); err != nil {
+		data := &ErrorData{
 			Debug: errEncoder.Encode(err.Error()),
-		})
+		}
+		var fieldErr *FieldDecodeError
+		if errors.As(err, &fieldErr) {
+			data.Path = []any{fieldErr.Field}
+		}
+		return ErrInvalidParams.CloneWithData(data)
 	}
 
 	// optional: if bindata is set set a field called BinData in the target struct
@@ -70,15 +123,18 @@ func (r *RPCRequest) UnmarshalAndValidate(errEncoder Secret, out any, bindata []
 		}
 	}
 
-	// start validation process
-	// TODO: return Validate(errEncoder, out)
 	return nil
 }
 
 // RPCResponseHeader object
 type RPCResponseHeader struct {
-	Version string          `json:"jsonrpc"`
-	ID      json.RawMessage `json:"id"`
+	Version  string          `json:"jsonrpc"`
+	ID       json.RawMessage `json:"id"`
+	Warnings []*Warning      `json:"warnings,omitempty"`
+
+	// duration is the server's processing time;
+	// rendered as Server-Timing header by http transports
+	duration *time.Duration
 }
 
 // NewRPCResponseHeader returns a new ResponseHeader
@@ -107,6 +163,10 @@ func NewRPCErrorResponse(id json.RawMessage, e *Error) *RPCErrorResponse {
 type RPCResultResponse struct {
 	RPCResponseHeader
 	Result any `json:"result"`
+
+	// cacheControl contains the caching directives
+	// set by the method; used by the http method handler
+	cacheControl *CacheControl
 }
 
 // NewRPCResultResponse returns a new ResultResponse
@@ -121,13 +181,15 @@ func NewRPCResultResponse(id json.RawMessage, result any) *RPCResultResponse {
 // a call towards an RPC method happened
 type RPCMeta struct {
 	Method string
+	// RequestID correlates logs and errors of a single request
+	RequestID string
 	// we might add more fields here in the future
 }
 
 var TypeRPCMeta = reflect.TypeOf((**RPCMeta)(nil)).Elem()
 
 func RequireRPCMeta(ctx *Context) *RPCMeta {
-	if v := ctx.Require(TypeHTTPResponseWriter); v != nil {
+	if v := ctx.Require(TypeRPCMeta); v != nil {
 		// we do return a copy here
 		x := *v.(*RPCMeta)
 		return &x
@@ -0,0 +1,41 @@
+package jonson
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ServerTimingHeader is the http header containing the server's processing time
+const ServerTimingHeader = "Server-Timing"
+
+// ServerTimingMetric is the name of the metric within the Server-Timing header
+const ServerTimingMetric = "app"
+
+// SetServerTiming enables reporting the time spent processing each call.
+// Http transports set the Server-Timing header (e.g. "app;dur=12.5"),
+// the meta envelope reports the duration in milliseconds.
+// The duration is measured using the method handler's Clock.
+func (m *MethodHandler) SetServerTiming(enabled bool) {
+	m.serverTiming = enabled
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// writeServerTiming sets the Server-Timing header in case
+// the response carries a duration
+func writeServerTiming(w http.ResponseWriter, resp any) {
+	var header *RPCResponseHeader
+	switch v := resp.(type) {
+	case *RPCResultResponse:
+		header = &v.RPCResponseHeader
+	case *RPCErrorResponse:
+		header = &v.RPCResponseHeader
+	}
+	if header == nil || header.duration == nil {
+		return
+	}
+	w.Header().Set(ServerTimingHeader, ServerTimingMetric+";dur="+strconv.FormatFloat(durationMillis(*header.duration), 'f', -1, 64))
+}
@@ -0,0 +1,70 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServerTiming(t *testing.T) {
+	clock := &contextTestClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	mh.SetClock(clock)
+	mh.RegisterMethod(&MethodDefinition{
+		System:  "server-timing-test",
+		Method:  "work",
+		Version: 1,
+		HandlerFunc: func(ctx *Context) (string, error) {
+			clock.now = clock.now.Add(12500 * time.Microsecond)
+			return "done", nil
+		},
+	})
+
+	call := func(t *testing.T, envelope string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/server-timing-test/work.v1", nil)
+		req.Header.Set(ResultEnvelopeHeader, envelope)
+		w := httptest.NewRecorder()
+		NewHttpMethodHandler(mh).Handle(w, req)
+		return w
+	}
+
+	t.Run("expect no server timing by default", func(t *testing.T) {
+		if v := call(t, "").Header().Get(ServerTimingHeader); v != "" {
+			t.Fatalf("expected no server timing, got: %s", v)
+		}
+	})
+
+	mh.SetServerTiming(true)
+
+	t.Run("expect server timing header to reflect the processing time", func(t *testing.T) {
+		if v := call(t, "").Header().Get(ServerTimingHeader); v != "app;dur=12.5" {
+			t.Fatalf("expected app;dur=12.5, got: %s", v)
+		}
+	})
+
+	t.Run("expect meta envelope to contain the duration", func(t *testing.T) {
+		w := call(t, ResultEnvelopeMeta)
+		out := struct {
+			Meta *ResultMeta `json:"meta"`
+		}{}
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Meta == nil || out.Meta.Duration == nil || *out.Meta.Duration != 12.5 {
+			t.Fatalf("expected duration of 12.5ms, got: %s", w.Body.String())
+		}
+	})
+
+	t.Run("expect rpc responses to carry the header", func(t *testing.T) {
+		body := []byte(`{"jsonrpc":"2.0","id":1,"method":"server-timing-test/work.v1"}`)
+		w := httptest.NewRecorder()
+		NewHttpRpcHandler(mh, "/rpc").Handle(w, httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(body)))
+		if v := w.Header().Get(ServerTimingHeader); v != "app;dur=12.5" {
+			t.Fatalf("expected app;dur=12.5, got: %s", v)
+		}
+	})
+}
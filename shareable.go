@@ -0,0 +1,120 @@
+package jonson
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// shareableSafeguard defines values which may be shared within a connection
+type shareableSafeguard interface {
+	_isShareable()
+}
+
+// Shareable may be embedded in provided values which should be shared
+// among all calls of a single websocket connection:
+// the value will be provisioned once per connection instead of once per call
+// and will be finalized once the connection closed and its calls returned.
+// Dependencies required by the shareable's provider share its lifetime;
+// the provider may require the connection's http request, WSClient and secret.
+// Shareable values must be safe for concurrent use.
+//
+//	type Session struct {
+//		jonson.Shareable
+//	}
+type Shareable struct {
+}
+
+func (s *Shareable) _isShareable() {}
+
+var typeShareableSafeguard = reflect.TypeOf((*shareableSafeguard)(nil)).Elem()
+
+func isShareable(rt reflect.Type) bool {
+	return rt.Kind() == reflect.Pointer && rt.Implements(typeShareableSafeguard)
+}
+
+// ShareablePolicy defines how failing provisioning of
+// shareable values is handled
+type ShareablePolicy int
+
+const (
+	// ShareableCacheFailures caches the failure: subsequent calls
+	// of the same connection requiring the value will fail
+	// without re-attempting to provision the value
+	ShareableCacheFailures ShareablePolicy = iota
+	// ShareableRetryFailures does not cache failures: the next call
+	// requiring the value will re-attempt provisioning. Calls not
+	// requiring the value remain unaffected.
+	ShareableRetryFailures
+)
+
+type sharedValue struct {
+	mu      sync.Mutex
+	val     any
+	failure any
+	valid   bool
+}
+
+// sharedValues stores all values shared within a connection
+type sharedValues struct {
+	policy ShareablePolicy
+	// ctx is owned by the connection: shared values are provisioned within
+	// (as plain values of ctx), so their dependencies live as long as the connection
+	ctx    *Context
+	mu     sync.Mutex
+	values map[reflect.Type]*sharedValue
+}
+
+func newSharedValues(parent context.Context, provider Provider, methodHandler *MethodHandler, policy ShareablePolicy) *sharedValues {
+	return &sharedValues{
+		policy: policy,
+		ctx:    NewContext(parent, provider, methodHandler),
+		values: map[reflect.Type]*sharedValue{},
+	}
+}
+
+// require returns the shared value of the given type;
+// the value will be provisioned using the connection's context in case
+// it does not exist yet
+func (s *sharedValues) require(rt reflect.Type) any {
+	s.mu.Lock()
+	v, ok := s.values[rt]
+	if !ok {
+		v = &sharedValue{}
+		s.values[rt] = v
+	}
+	s.mu.Unlock()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.valid {
+		return v.val
+	}
+	if v.failure != nil {
+		panic(v.failure)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				if s.policy == ShareableCacheFailures {
+					v.failure = r
+				}
+				panic(r)
+			}
+		}()
+		v.val = s.ctx.Require(rt)
+		v.valid = true
+	}()
+	return v.val
+}
+
+// finalize finalizes all shared values and their dependencies
+// from end to front by finalizing the connection's context
+func (s *sharedValues) finalize() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = map[reflect.Type]*sharedValue{}
+	s.ctx.Finalize(nil)
+}
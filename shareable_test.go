@@ -0,0 +1,144 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type shareableTestDB struct {
+	Shareable
+	finalized bool
+}
+
+func (s *shareableTestDB) Finalize(errs []error) error {
+	s.finalized = true
+	return nil
+}
+
+var typeShareableTestDB = reflect.TypeOf((**shareableTestDB)(nil)).Elem()
+
+// newShareableTestFactory returns a factory whose db provider fails
+// on the first attempt and succeeds afterwards
+func newShareableTestFactory() (*Factory, *int) {
+	attempts := 0
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *shareableTestDB {
+		attempts++
+		if attempts == 1 {
+			panic(errors.New("db is down"))
+		}
+		return &shareableTestDB{}
+	})
+	return fac, &attempts
+}
+
+// requireShared creates a new context for each call (simulating
+// a single message) and requires the shared db
+func requireShared(fac *Factory, shared *sharedValues) (db *shareableTestDB, err error) {
+	ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+	ctx.shared = shared
+	defer func() {
+		if r := recover(); r != nil {
+			err = getRecoverError(r)
+		}
+		ctx.Finalize(err)
+	}()
+	return ctx.Require(typeShareableTestDB).(*shareableTestDB), nil
+}
+
+func TestShareable(t *testing.T) {
+	t.Run("expect shareable to be provisioned once per connection", func(t *testing.T) {
+		fac, attempts := newShareableTestFactory()
+		*attempts = 1
+		shared := newSharedValues(context.Background(), fac, nil, ShareableCacheFailures)
+
+		db1, _ := requireShared(fac, shared)
+		db2, _ := requireShared(fac, shared)
+		if db1 != db2 {
+			t.Fatal("expected the same instance to be shared")
+		}
+		if db1.finalized {
+			t.Fatal("expected shared value not to be finalized by the call's context")
+		}
+		shared.finalize()
+		if !db1.finalized {
+			t.Fatal("expected shared value to be finalized with the connection")
+		}
+	})
+
+	t.Run("expect failures to be cached", func(t *testing.T) {
+		fac, attempts := newShareableTestFactory()
+		shared := newSharedValues(context.Background(), fac, nil, ShareableCacheFailures)
+
+		if _, err := requireShared(fac, shared); err == nil {
+			t.Fatal("expected first attempt to fail")
+		}
+		if _, err := requireShared(fac, shared); err == nil {
+			t.Fatal("expected cached failure")
+		}
+		if *attempts != 1 {
+			t.Fatalf("expected a single provisioning attempt, got: %d", *attempts)
+		}
+	})
+
+	t.Run("expect failures to be retried on next require", func(t *testing.T) {
+		fac, attempts := newShareableTestFactory()
+		shared := newSharedValues(context.Background(), fac, nil, ShareableRetryFailures)
+
+		if _, err := requireShared(fac, shared); err == nil {
+			t.Fatal("expected first attempt to fail")
+		}
+		db, err := requireShared(fac, shared)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if db == nil || *attempts != 2 {
+			t.Fatalf("expected second attempt to succeed, got %d attempts", *attempts)
+		}
+	})
+}
+
+type shareableTestConn struct {
+	finalized bool
+}
+
+func (s *shareableTestConn) Finalize(errs []error) error {
+	s.finalized = true
+	return nil
+}
+
+type shareableTestSession struct {
+	Shareable
+	conn *shareableTestConn
+}
+
+func TestShareableDependencies(t *testing.T) {
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *shareableTestConn {
+		return &shareableTestConn{}
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *shareableTestSession {
+		return &shareableTestSession{conn: Require[*shareableTestConn](ctx)}
+	})
+
+	t.Run("expect dependencies of shared values to live as long as the connection", func(t *testing.T) {
+		shared := newSharedValues(context.Background(), fac, nil, ShareableCacheFailures)
+
+		ctx := NewContext(context.Background(), fac, nil)
+		ctx.shared = shared
+		session := Require[*shareableTestSession](ctx)
+		if err := ctx.Finalize(nil); err != nil {
+			t.Fatal(err)
+		}
+		if session.conn.finalized {
+			t.Fatal("expected dependency not to be finalized by the call's context")
+		}
+
+		shared.finalize()
+		if !session.conn.finalized {
+			t.Fatal("expected dependency to be finalized with the connection")
+		}
+	})
+}
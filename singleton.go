@@ -0,0 +1,232 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"sync"
+)
+
+// singletonSafeguard defines values which may be shared by all requests
+type singletonSafeguard interface {
+	_isSingleton()
+}
+
+// Singleton may be embedded in provided values which should be shared
+// among all requests of a method handler: the value will be provisioned once
+// and kept until it gets replaced using ReloadSingleton.
+// Singleton values must be safe for concurrent use. Singletons are provisioned
+// within a context owned by the method handler: their dependencies live
+// as long as the singleton instance and are finalized along with it.
+// Being request independent, their providers may require the secret
+// but neither the http request nor any other request scoped value.
+// Close finalizes all singletons on shutdown.
+//
+//	type FeatureFlags struct {
+//		jonson.Singleton
+//	}
+type Singleton struct {
+}
+
+func (s *Singleton) _isSingleton() {}
+
+var typeSingletonSafeguard = reflect.TypeOf((*singletonSafeguard)(nil)).Elem()
+
+func isSingleton(rt reflect.Type) bool {
+	return rt.Kind() == reflect.Pointer && rt.Implements(typeSingletonSafeguard)
+}
+
+// singletonInstance is a single generation of a singleton value;
+// replaced instances are finalized once the last context holding them finalizes
+type singletonInstance struct {
+	owner *singletonValue
+	val   any
+	// ctx holds the dependencies of the instance
+	ctx  *Context
+	refs int
+	// retired is set once the instance has been replaced
+	retired bool
+}
+
+type singletonValue struct {
+	rt      reflect.Type
+	mu      sync.Mutex
+	current *singletonInstance
+}
+
+// singletonValues stores all singletons of a method handler
+type singletonValues struct {
+	methodHandler *MethodHandler
+	mu            sync.Mutex
+	values        map[reflect.Type]*singletonValue
+	// order contains the values in the order they were first required
+	order []*singletonValue
+}
+
+func newSingletonValues(methodHandler *MethodHandler) *singletonValues {
+	return &singletonValues{
+		methodHandler: methodHandler,
+		values:        map[reflect.Type]*singletonValue{},
+	}
+}
+
+// provide provisions a new instance within a context owned by the method handler;
+// the context is finalized in case provisioning fails
+func (s *singletonValues) provide(v *singletonValue) (inst *singletonInstance) {
+	ctx := NewContext(context.Background(), s.methodHandler.provider, s.methodHandler)
+	ctx.StoreValue(TypeSecret, s.methodHandler.errorEncoder)
+	defer func() {
+		if r := recover(); r != nil {
+			ctx.Finalize(getRecoverError(r))
+			panic(r)
+		}
+	}()
+	// the placeholder reveals recursion loops; being a singleton,
+	// the value itself will not be finalized by ctx
+	item, _, err := ctx.reserve(v.rt)
+	if err != nil {
+		panic(err)
+	}
+	val := ctx.provider.Provide(ctx.provisioningView(item), v.rt)
+	ctx.complete(item, val)
+	return &singletonInstance{
+		owner: v,
+		val:   val,
+		ctx:   ctx,
+	}
+}
+
+func (s *singletonValues) value(rt reflect.Type) *singletonValue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[rt]
+	if !ok {
+		v = &singletonValue{rt: rt}
+		s.values[rt] = v
+		s.order = append(s.order, v)
+	}
+	return v
+}
+
+// acquire returns the current instance of the given type;
+// the instance will be provisioned in case it does not exist yet.
+// Failures are not cached.
+func (s *singletonValues) acquire(rt reflect.Type) *singletonInstance {
+	v := s.value(rt)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.current == nil {
+		v.current = s.provide(v)
+	}
+	v.current.refs++
+	return v.current
+}
+
+// release releases a reference acquired by a context
+func (i *singletonInstance) release() {
+	i.owner.mu.Lock()
+	i.refs--
+	finalize := i.retired && i.refs == 0
+	i.owner.mu.Unlock()
+	if finalize {
+		if err := i.finalize(); err != nil {
+			log.Printf("singleton: failed to finalize replaced %s: %s", i.owner.rt, err)
+		}
+	}
+}
+
+// finalize finalizes the instance followed by its dependencies
+func (i *singletonInstance) finalize() error {
+	var err error
+	if f, ok := i.val.(Finalizeable); ok {
+		err = f.Finalize(nil)
+	}
+	return i.ctx.Finalize(err)
+}
+
+// reload provisions a new instance and swaps it with the current one
+func (s *singletonValues) reload(rt reflect.Type) (err error) {
+	v := s.value(rt)
+	var inst *singletonInstance
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = getRecoverError(r)
+			}
+		}()
+		inst = s.provide(v)
+	}()
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	old := v.current
+	v.current = inst
+	finalize := false
+	if old != nil {
+		old.retired = true
+		finalize = old.refs == 0
+	}
+	v.mu.Unlock()
+
+	if finalize {
+		return old.finalize()
+	}
+	return nil
+}
+
+// close retires all current instances from end to front; instances still
+// held by contexts are finalized once released by their last context
+func (s *singletonValues) close() error {
+	s.mu.Lock()
+	values := append([]*singletonValue{}, s.order...)
+	s.mu.Unlock()
+
+	var err error
+	for i := len(values) - 1; i >= 0; i-- {
+		v := values[i]
+		v.mu.Lock()
+		old := v.current
+		v.current = nil
+		finalize := false
+		if old != nil {
+			old.retired = true
+			finalize = old.refs == 0
+		}
+		v.mu.Unlock()
+
+		if !finalize {
+			continue
+		}
+		if e := old.finalize(); e != nil {
+			log.Printf("singleton: failed to finalize %s: %s", v.rt, e)
+			if err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
+// Close finalizes all singletons along with their dependencies, e.g. on shutdown;
+// instances still in use by requests are finalized once the last of those
+// requests finished. Singletons required afterwards will be provisioned again.
+// The first finalize error is returned.
+func (m *MethodHandler) Close() error {
+	return m.singletons.close()
+}
+
+// ReloadSingleton provisions a fresh instance of the given singleton type
+// and swaps it with the current instance, e.g. after a config reload
+// or a certificate rotation. Requests which already required the singleton
+// keep using the current instance; it will be finalized once the last of
+// those requests finished. The current instance remains in place
+// in case provisioning fails.
+func (m *MethodHandler) ReloadSingleton(rt reflect.Type) error {
+	if !isSingleton(rt) {
+		return errors.New("method handler: " + rt.String() + " is not a singleton")
+	}
+	return m.singletons.reload(rt)
+}
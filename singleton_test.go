@@ -0,0 +1,199 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type singletonTestConfig struct {
+	Singleton
+	Version   int64
+	finalized atomic.Bool
+}
+
+func (s *singletonTestConfig) Finalize(errs []error) error {
+	s.finalized.Store(true)
+	return nil
+}
+
+var typeSingletonTestConfig = TypeOf[*singletonTestConfig]()
+
+func TestReloadSingleton(t *testing.T) {
+	var (
+		version atomic.Int64
+		fail    atomic.Bool
+	)
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *singletonTestConfig {
+		if fail.Load() {
+			panic(errors.New("config unavailable"))
+		}
+		return &singletonTestConfig{Version: version.Add(1)}
+	})
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	t.Run("expect singleton to be shared among contexts", func(t *testing.T) {
+		a := NewContext(context.Background(), fac, mh)
+		b := NewContext(context.Background(), fac, mh)
+		if Require[*singletonTestConfig](a) != Require[*singletonTestConfig](b) {
+			t.Fatal("expected contexts to share the singleton")
+		}
+		if err := a.Finalize(nil); err != nil {
+			t.Fatal(err)
+		}
+		if Require[*singletonTestConfig](b).finalized.Load() {
+			t.Fatal("expected singleton not to be finalized by the context")
+		}
+		b.Finalize(nil)
+	})
+
+	t.Run("expect in-flight requests to keep the old instance", func(t *testing.T) {
+		inFlight := NewContext(context.Background(), fac, mh)
+		old := Require[*singletonTestConfig](inFlight)
+
+		if err := mh.ReloadSingleton(typeSingletonTestConfig); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := NewContext(context.Background(), fac, mh)
+		fresh := Require[*singletonTestConfig](ctx)
+		if fresh == old || fresh.Version <= old.Version {
+			t.Fatalf("expected a new instance, got version %d after %d", fresh.Version, old.Version)
+		}
+		if Require[*singletonTestConfig](inFlight) != old {
+			t.Fatal("expected in-flight request to keep the old instance")
+		}
+		if old.finalized.Load() {
+			t.Fatal("expected old instance not to be finalized while in use")
+		}
+		inFlight.Finalize(nil)
+		if !old.finalized.Load() {
+			t.Fatal("expected old instance to be finalized once released")
+		}
+		if fresh.finalized.Load() {
+			t.Fatal("expected new instance not to be finalized")
+		}
+		ctx.Finalize(nil)
+	})
+
+	t.Run("expect failing reload to keep the current instance", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		current := Require[*singletonTestConfig](ctx)
+		ctx.Finalize(nil)
+
+		fail.Store(true)
+		defer fail.Store(false)
+		if err := mh.ReloadSingleton(typeSingletonTestConfig); err == nil {
+			t.Fatal("expected reload to fail")
+		}
+		ctx = NewContext(context.Background(), fac, mh)
+		defer ctx.Finalize(nil)
+		if Require[*singletonTestConfig](ctx) != current || current.finalized.Load() {
+			t.Fatal("expected current instance to remain in place")
+		}
+	})
+
+	t.Run("expect non singleton types to be rejected", func(t *testing.T) {
+		if err := mh.ReloadSingleton(TypeOf[*contextTestUser]()); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("expect concurrent reloads to be safe", func(t *testing.T) {
+		wg := sync.WaitGroup{}
+		for i := 0; i < 20; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				ctx := NewContext(context.Background(), fac, mh)
+				defer ctx.Finalize(nil)
+				if cfg := Require[*singletonTestConfig](ctx); cfg.finalized.Load() {
+					t.Error("expected held instance not to be finalized")
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				if err := mh.ReloadSingleton(typeSingletonTestConfig); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+type singletonTestConn struct {
+	finalized atomic.Bool
+}
+
+func (s *singletonTestConn) Finalize(errs []error) error {
+	s.finalized.Store(true)
+	return nil
+}
+
+type singletonTestClient struct {
+	Singleton
+	conn *singletonTestConn
+}
+
+func TestSingletonDependencies(t *testing.T) {
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *singletonTestConn {
+		return &singletonTestConn{}
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *singletonTestClient {
+		if RequireSecret(ctx) == nil {
+			panic(errors.New("expected secret to be available"))
+		}
+		return &singletonTestClient{conn: Require[*singletonTestConn](ctx)}
+	})
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+
+	t.Run("expect dependencies to live as long as the singleton instance", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		client := Require[*singletonTestClient](ctx)
+		if err := ctx.Finalize(nil); err != nil {
+			t.Fatal(err)
+		}
+		if client.conn.finalized.Load() {
+			t.Fatal("expected dependency not to be finalized by the first request")
+		}
+
+		if err := mh.ReloadSingleton(TypeOf[*singletonTestClient]()); err != nil {
+			t.Fatal(err)
+		}
+		if !client.conn.finalized.Load() {
+			t.Fatal("expected dependency to be finalized along with the replaced instance")
+		}
+	})
+
+	t.Run("expect close to finalize the current instance", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, mh)
+		client := Require[*singletonTestClient](ctx)
+
+		if err := mh.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if client.conn.finalized.Load() {
+			t.Fatal("expected instance in use not to be finalized")
+		}
+		if err := ctx.Finalize(nil); err != nil {
+			t.Fatal(err)
+		}
+		if !client.conn.finalized.Load() {
+			t.Fatal("expected instance to be finalized once released")
+		}
+
+		ctx = NewContext(context.Background(), fac, mh)
+		if Require[*singletonTestClient](ctx) == client {
+			t.Fatal("expected a new instance after close")
+		}
+		ctx.Finalize(nil)
+		if err := mh.Close(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package jonson
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// B3 propagation headers used to continue incoming traces
+const (
+	TraceIDHeader      = "X-B3-TraceId"
+	SpanIDHeader       = "X-B3-SpanId"
+	ParentSpanIDHeader = "X-B3-ParentSpanId"
+	SampledHeader      = "X-B3-Sampled"
+)
+
+// Span tags set by jonson
+const (
+	SpanTagMethod     = "jonson.method"
+	SpanTagRequestID  = "jonson.request_id"
+	SpanTagResultSize = "jonson.result_size"
+	SpanTagErrorCode  = "jonson.error_code"
+)
+
+// Span describes a single method call
+type Span struct {
+	TraceID  string
+	SpanID   string
+	ParentID string
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+	Sampled  bool
+	Tags     map[string]string
+}
+
+// SpanExporter exports finished spans; Export is called
+// during the request and must not block, e.g. by batching spans
+// and exporting them asynchronously.
+type SpanExporter interface {
+	Export(span *Span)
+}
+
+// TracingOptions configure tracing of method calls
+type TracingOptions struct {
+	Exporter SpanExporter
+	// SampleRate defines the fraction (0..1) of traces to be sampled;
+	// sampling decisions of incoming traces will be honored
+	SampleRate float64
+}
+
+// SetTracing enables tracing: each method call becomes a span,
+// nested calls using CallMethod become child spans.
+// Incoming traces are continued using the B3 headers.
+func (m *MethodHandler) SetTracing(options *TracingOptions) {
+	m.tracing = options
+}
+
+// TraceSpan returns the span of the method currently being called;
+// nil is returned in case tracing is disabled
+func TraceSpan(ctx *Context) *Span {
+	return ctx.span
+}
+
+func randomTraceID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// sample returns the sampling decision of new traces
+func (o *TracingOptions) sample() bool {
+	if o.SampleRate >= 1 {
+		return true
+	}
+	if o.SampleRate <= 0 {
+		return false
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(1<<53))
+	if err != nil {
+		return false
+	}
+	return float64(n.Int64())/float64(1<<53) < o.SampleRate
+}
+
+// startSpan starts a span for the given method;
+// the span continues the context's current span or the incoming trace
+func (m *MethodHandler) startSpan(ctx *Context, method string) *Span {
+	span := &Span{
+		SpanID: randomTraceID(8),
+		Name:   method,
+		Start:  time.Now(),
+		Tags: map[string]string{
+			SpanTagMethod: method,
+		},
+	}
+	if requestID := requestIDOf(ctx); requestID != "" {
+		span.Tags[SpanTagRequestID] = requestID
+	}
+
+	if parent := ctx.span; parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+		span.Sampled = parent.Sampled
+		return span
+	}
+
+	var r *http.Request
+	if v, ok := ctx.lookup(TypeHTTPRequest); ok {
+		r, _ = v.(*http.Request)
+	}
+	if r != nil && r.Header.Get(TraceIDHeader) != "" {
+		span.TraceID = r.Header.Get(TraceIDHeader)
+		span.ParentID = r.Header.Get(SpanIDHeader)
+		if sampled := r.Header.Get(SampledHeader); sampled != "" {
+			span.Sampled = sampled == "1" || sampled == "true"
+			return span
+		}
+	} else {
+		span.TraceID = randomTraceID(16)
+	}
+	span.Sampled = m.tracing.sample()
+	return span
+}
+
+// endSpan finishes and exports the span
+func (m *MethodHandler) endSpan(span *Span, res any, err error) {
+	span.Duration = time.Since(span.Start)
+	if !span.Sampled {
+		return
+	}
+	if err != nil {
+		span.Tags[SpanTagErrorCode] = strconv.Itoa(AsError(err).Code)
+	} else if res != nil {
+		// the response has not been encoded yet and the results
+		// of nested calls never are; the size is the json encoded size
+		if b, err := json.Marshal(res); err == nil {
+			span.Tags[SpanTagResultSize] = strconv.Itoa(len(b))
+		}
+	}
+	m.tracing.Exporter.Export(span)
+}
+
+// traceMethod calls the method within a new span
+func (m *MethodHandler) traceMethod(ctx *Context, rpcRequest *RPCRequest, bindata []byte) (res any, err error) {
+	span := m.startSpan(ctx, rpcRequest.Method)
+	parent := ctx.span
+	ctx.span = span
+	defer func() {
+		ctx.span = parent
+		m.endSpan(span, res, err)
+	}()
+	return m.route(ctx, rpcRequest, bindata)
+}
@@ -5,76 +5,167 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 )
 
-// Validate validates the given object
+// Validate validates the given object.
+// Validators are compiled once per type and cached.
 func Validate(errEncoder Secret, obj any) error {
-	if errs := validate(errEncoder, obj, nil); len(errs) > 0 {
+	rv := reflect.ValueOf(obj)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if !rv.CanAddr() {
+		// validator methods use pointer receivers
+		cp := reflect.New(rv.Type()).Elem()
+		cp.Set(rv)
+		rv = cp
+	}
+
+	return toValidationError(compileValidator(rv.Type()).validate(errEncoder, rv, nil))
+}
+
+func toValidationError(errs []*Error) error {
+	if len(errs) > 0 {
 		return ErrInvalidParams.CloneWithData(&ErrorData{
 			Details: errs,
 		})
 	}
-
 	return nil
 }
 
-func validate(errEncoder Secret, obj any, currentPath []any) []*Error {
-	var errs []*Error
+// validator is the compiled validation of a struct type
+type validator struct {
+	fields []*validatorField
+}
 
-	rv := reflect.ValueOf(obj)
-	rt := toPointer(reflect.TypeOf(obj))
-	rte := rt.Elem()
+type validatorField struct {
+	index int
+	name  string
+	// method is the Validate<Field> method; might be invalid
+	method reflect.Value
+	// nested contains the validator of nested structs; might be nil
+	nested *validator
+	ptr    bool
+}
 
-	for i := 0; i < rte.NumField(); i++ {
-		rtf := rte.Field(i)
+var (
+	validators     sync.Map
+	validatorsMu   sync.Mutex
+	typeErrorIface = reflect.TypeOf((*error)(nil)).Elem()
+	emptyValidator = &validator{}
+)
+
+// compileValidator returns the cached validator of the given struct type;
+// the validator will be compiled in case it does not exist yet
+func compileValidator(rt reflect.Type) *validator {
+	if v, ok := validators.Load(rt); ok {
+		return v.(*validator)
+	}
+
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	compiled := map[reflect.Type]*validator{}
+	v := newValidator(rt, compiled)
+	for k, c := range compiled {
+		validators.Store(k, c)
+	}
+	return v
+}
+
+// newValidator compiles the validator of the given type;
+// compiled keeps track of all validators compiled within the current run
+// to support recursive types
+func newValidator(rt reflect.Type, compiled map[reflect.Type]*validator) *validator {
+	if v, ok := validators.Load(rt); ok {
+		return v.(*validator)
+	}
+	if v, ok := compiled[rt]; ok {
+		return v
+	}
+
+	v := &validator{}
+	compiled[rt] = v
+	prt := reflect.PointerTo(rt)
+
+	for i := 0; i < rt.NumField(); i++ {
+		rtf := rt.Field(i)
 		if rtf.PkgPath != "" {
 			// skip private fields
 			continue
 		}
 
-		name := rtf.Name
-
-		// extract json field name
-		if jt, ok := rtf.Tag.Lookup("json"); ok {
-			if p := strings.Split(jt, ","); len(p) > 0 && len(p[0]) > 0 {
-				if p[0] == "-" {
-					// skip fields we don't want in json
-					continue
-				}
-				name = p[0]
-			}
+		name, ok := jsonFieldName(rtf)
+		if !ok {
+			// skip fields we don't want in json
+			continue
 		}
 
-		// build path for current field member
-		path := append(append([]any(nil), currentPath...), name)
+		field := &validatorField{
+			index: i,
+			name:  name,
+		}
 
 		// do we have validation happening on the struct's field itself?
-		rtv := rv.Field(i)
-		if rtv.Kind() == reflect.Struct {
-			if nestedStructErrors := validate(errEncoder, rtv.Interface(), path); nestedStructErrors != nil {
-				errs = append(errs, nestedStructErrors...)
-			}
+		switch {
+		case rtf.Type.Kind() == reflect.Struct:
+			field.nested = newValidator(rtf.Type, compiled)
+		case rtf.Type.Kind() == reflect.Pointer && rtf.Type.Elem().Kind() == reflect.Struct:
+			field.nested = newValidator(rtf.Type.Elem(), compiled)
+			field.ptr = true
 		}
 
 		// check if valider exists for field
-		if m, ok := rt.MethodByName("Validate" + rtf.Name); ok {
-			res := m.Func.Call([]reflect.Value{rv})
-
-			if err, ok := res[0].Interface().(*Error); ok && err != nil {
-				errs = append(errs, err.CloneWithData(&ErrorData{
-					Path: path,
-				}))
-				continue
-			}
+		if m, ok := prt.MethodByName("Validate" + rtf.Name); ok &&
+			m.Type.NumIn() == 1 && m.Type.NumOut() == 1 && m.Type.Out(0).Implements(typeErrorIface) {
+			field.method = m.Func
+		}
 
-			if err, ok := res[0].Interface().(error); ok && err != nil {
-				errs = append(errs, ErrInternal.CloneWithData(&ErrorData{
-					Path:  path,
-					Debug: errEncoder.Encode(err.Error()),
-				}))
-				continue
+		if field.nested != nil || field.method.IsValid() {
+			v.fields = append(v.fields, field)
+		}
+	}
+	return v
+}
+
+// validate validates the given struct value which needs to be addressable
+func (v *validator) validate(errEncoder Secret, rv reflect.Value, currentPath []any) []*Error {
+	var errs []*Error
+
+	for _, f := range v.fields {
+		// build path for current field member
+		path := append(append([]any(nil), currentPath...), f.name)
+
+		if f.nested != nil {
+			rvf := rv.Field(f.index)
+			if f.ptr {
+				rvf = rvf.Elem()
+			}
+			if rvf.IsValid() {
+				errs = append(errs, f.nested.validate(errEncoder, rvf, path)...)
 			}
 		}
+
+		if !f.method.IsValid() {
+			continue
+		}
+
+		res := f.method.Call([]reflect.Value{rv.Addr()})
+
+		if err, ok := res[0].Interface().(*Error); ok && err != nil {
+			errs = append(errs, err.CloneWithData(&ErrorData{
+				Path: path,
+			}))
+			continue
+		}
+
+		if err, ok := res[0].Interface().(error); ok && err != nil {
+			errs = append(errs, ErrInternal.CloneWithData(&ErrorData{
+				Path:  path,
+				Debug: errEncoder.Encode(err.Error()),
+			}))
+			continue
+		}
 	}
 
 	return errs
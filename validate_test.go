@@ -1,6 +1,7 @@
 package jonson
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -50,3 +51,134 @@ func TestValidate(t *testing.T) {
 		}
 	})
 }
+
+func TestValidateCompiled(t *testing.T) {
+	t.Run("expect invalid fields to be reported", func(t *testing.T) {
+		err := Validate(NewDebugSecret(), &Profile{Image: &Image{URL: "url", UUID: "uuid"}})
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			t.Fatalf("expected *Error, got: %v", err)
+		}
+		paths := []string{}
+		for _, v := range rpcErr.Data.Details {
+			paths = append(paths, fmt.Sprintf("%v", v.Data.Path))
+		}
+		expected := []string{"[name]", "[imageRequired URL]", "[imageRequired UUID]"}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Fatalf("expected paths %v, got: %v", expected, paths)
+		}
+	})
+
+	t.Run("expect validator to be compiled once", func(t *testing.T) {
+		rt := reflect.TypeOf(Profile{})
+		if compileValidator(rt) != compileValidator(rt) {
+			t.Fatal("expected cached validator")
+		}
+	})
+}
+
+type validateTestObserver struct {
+	NopObserver
+	runs     int
+	failures int
+}
+
+func (v *validateTestObserver) ObserveValidation(method string, err error) {
+	v.runs++
+	if err != nil {
+		v.failures++
+	}
+}
+
+type ValidateTest struct{}
+
+type validateTestSetV1Params struct {
+	Params
+	Name string `json:"name"`
+}
+
+func (v *validateTestSetV1Params) ValidateName() error {
+	if len(v.Name) < 1 {
+		return ErrInvalidParams
+	}
+	return nil
+}
+
+func (v *ValidateTest) SetV1(ctx *Context, params *validateTestSetV1Params) error {
+	return nil
+}
+
+func TestValidateObserver(t *testing.T) {
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	observer := &validateTestObserver{}
+	mh.SetObserver(observer)
+	mh.RegisterSystem(&ValidateTest{})
+
+	callRPC(t, mh, "validate-test/set.v1", map[string]any{"name": "Silvio"})
+	resp := callRPC(t, mh, "validate-test/set.v1", map[string]any{"name": ""})
+	if _, ok := resp["error"]; !ok {
+		t.Fatal("expected validation to fail")
+	}
+	if observer.runs != 2 || observer.failures != 1 {
+		t.Fatalf("expected 2 runs and 1 failure, got: %d runs and %d failures", observer.runs, observer.failures)
+	}
+}
+
+type ValidateRejectTest struct {
+	calls int
+}
+
+func (v *ValidateRejectTest) SetV1(ctx *Context, params *validateTestSetV1Params) error {
+	v.calls++
+	return nil
+}
+
+func TestValidateRejection(t *testing.T) {
+	mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	system := &ValidateRejectTest{}
+	mh.RegisterSystem(system)
+
+	t.Run("expect invalid params to be rejected before calling the method", func(t *testing.T) {
+		resp := callRPC(t, mh, "validate-reject-test/set.v1", map[string]any{"name": ""})
+		rpcErr := &Error{}
+		if err := json.Unmarshal(resp["error"], rpcErr); err != nil {
+			t.Fatal(err)
+		}
+		if rpcErr.Code != ErrInvalidParams.Code || system.calls != 0 {
+			t.Fatalf("expected invalid params without calling the method, got: %+v and %d calls", rpcErr, system.calls)
+		}
+	})
+
+	t.Run("expect valid params to be passed to the method", func(t *testing.T) {
+		if resp := callRPC(t, mh, "validate-reject-test/set.v1", map[string]any{"name": "Silvio"}); resp["error"] != nil || system.calls != 1 {
+			t.Fatalf("expected method to be called, got: %s", resp["error"])
+		}
+	})
+
+	t.Run("expect decode and validate to execute validators", func(t *testing.T) {
+		req := &RPCRequest{Params: json.RawMessage(`{"name": ""}`)}
+		if err := req.UnmarshalAndValidate(NewDebugSecret(), &validateTestSetV1Params{}, nil); err == nil {
+			t.Fatal("expected validation to fail")
+		}
+	})
+}
+
+func BenchmarkValidate(b *testing.B) {
+	profile := &Profile{Name: "Silvio", ImageRequired: Image{URL: "url", UUID: "uuid"}}
+	rt := reflect.TypeOf(Profile{})
+	enc := NewDebugSecret()
+
+	b.Run("compile per request", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			validators.Delete(rt)
+			validators.Delete(reflect.TypeOf(Image{}))
+			compileValidator(rt).validate(enc, reflect.ValueOf(profile).Elem(), nil)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Validate(enc, profile)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package jonson
+
+// ValidationErrors accumulates field errors of business rule validations
+// which cannot be expressed using Validate<Field> methods:
+//
+//	ve := jonson.NewValidationErrors()
+//	if params.From.After(params.To) {
+//		ve.Add("to", "must not be before from")
+//	}
+//	if err := ve.AsError(); err != nil {
+//		return nil, err
+//	}
+//
+// The resulting error has the same shape as errors produced by
+// the automatic validation: each field error is listed within the details
+// using its path; additionally, Fields maps each field to its message.
+type ValidationErrors struct {
+	fields  []string
+	message map[string]string
+}
+
+func NewValidationErrors() *ValidationErrors {
+	return &ValidationErrors{
+		message: map[string]string{},
+	}
+}
+
+// Add adds a field error; only the first message
+// of a field will be kept
+func (v *ValidationErrors) Add(field string, message string) {
+	if _, ok := v.message[field]; ok {
+		return
+	}
+	v.fields = append(v.fields, field)
+	v.message[field] = message
+}
+
+// Len returns the number of fields with errors
+func (v *ValidationErrors) Len() int {
+	return len(v.fields)
+}
+
+// AsError returns an ErrInvalidParams error containing all field errors;
+// nil is returned in case no errors have been added
+func (v *ValidationErrors) AsError() *Error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	data := &ErrorData{
+		Fields: make(map[string]string, len(v.fields)),
+	}
+	for _, field := range v.fields {
+		data.Fields[field] = v.message[field]
+		data.Details = append(data.Details, &Error{
+			Code:    ErrInvalidParams.Code,
+			Message: v.message[field],
+			Data: &ErrorData{
+				Path: []any{field},
+			},
+		})
+	}
+	return ErrInvalidParams.CloneWithData(data)
+}
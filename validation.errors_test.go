@@ -0,0 +1,47 @@
+package jonson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidationErrors(t *testing.T) {
+	t.Run("expect nil without errors", func(t *testing.T) {
+		if err := NewValidationErrors().AsError(); err != nil {
+			t.Fatalf("expected nil, got: %v", err)
+		}
+	})
+
+	t.Run("expect a single field error", func(t *testing.T) {
+		ve := NewValidationErrors()
+		ve.Add("name", "must not be empty")
+		err := ve.AsError()
+		if err == nil || err.Code != ErrInvalidParams.Code {
+			t.Fatalf("expected invalid params, got: %v", err)
+		}
+		if !reflect.DeepEqual(err.Data.Fields, map[string]string{"name": "must not be empty"}) {
+			t.Fatalf("unexpected fields: %v", err.Data.Fields)
+		}
+		if len(err.Data.Details) != 1 || !reflect.DeepEqual(err.Data.Details[0].Data.Path, []any{"name"}) || err.Data.Details[0].Message != "must not be empty" {
+			t.Fatalf("unexpected details: %v", err.Data.Details)
+		}
+	})
+
+	t.Run("expect multiple field errors in order", func(t *testing.T) {
+		ve := NewValidationErrors()
+		ve.Add("from", "must be in the future")
+		ve.Add("to", "must not be before from")
+		ve.Add("from", "ignored")
+		err := ve.AsError()
+		if ve.Len() != 2 {
+			t.Fatalf("expected 2 fields, got: %d", ve.Len())
+		}
+		expected := map[string]string{"from": "must be in the future", "to": "must not be before from"}
+		if !reflect.DeepEqual(err.Data.Fields, expected) {
+			t.Fatalf("expected %v, got: %v", expected, err.Data.Fields)
+		}
+		if len(err.Data.Details) != 2 || err.Data.Details[0].Data.Path[0] != "from" || err.Data.Details[1].Data.Path[0] != "to" {
+			t.Fatalf("unexpected details: %v", err.Data.Details)
+		}
+	})
+}
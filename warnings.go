@@ -0,0 +1,60 @@
+package jonson
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Warning codes used by jonson
+const (
+	// WarningTimeoutBudget is added whenever a method
+	// ran close to its configured timeout
+	WarningTimeoutBudget = "timeout-budget"
+	// WarningUnregisteredError is added in strict error mode whenever
+	// a method returned an error which has not been registered
+	WarningUnregisteredError = "unregistered-error"
+)
+
+// Warning is a non-fatal message attached to the response
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var TypeWarnings = reflect.TypeOf((**Warnings)(nil)).Elem()
+
+// RequireWarnings returns the warnings of the ongoing request
+func RequireWarnings(ctx *Context) *Warnings {
+	if v := ctx.Require(TypeWarnings); v != nil {
+		return v.(*Warnings)
+	}
+	return nil
+}
+
+// Warnings collects warnings during a request;
+// the warnings will be attached to the rpc response.
+type Warnings struct {
+	mu   sync.Mutex
+	list []*Warning
+}
+
+func NewWarnings() *Warnings {
+	return &Warnings{}
+}
+
+// Add adds a new warning
+func (w *Warnings) Add(code string, message string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.list = append(w.list, &Warning{
+		Code:    code,
+		Message: message,
+	})
+}
+
+// List returns all warnings added so far
+func (w *Warnings) List() []*Warning {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]*Warning(nil), w.list...)
+}
@@ -1,6 +1,7 @@
 package jonson
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -28,14 +30,35 @@ type WebsocketHandler struct {
 	path          string
 	methodHandler *MethodHandler
 	options       *WebsocketOptions
+
+	mu       sync.Mutex
+	draining bool
+	clients  map[*WSClient]struct{}
+	inflight sync.WaitGroup
 }
 
+// ErrDrainGracePeriodExceeded is returned by Drain in case in-flight
+// calls had to be cancelled after the grace period passed
+var ErrDrainGracePeriodExceeded = errors.New("websocket handler: drain grace period exceeded")
+
+// DefaultDrainGracePeriod is used by Drain in case no grace period has been set
+const DefaultDrainGracePeriod = 30 * time.Second
+
 type WebsocketOptions struct {
 	Upgrader       *websocket.Upgrader
 	MaxMessageSize int64
 	PingPeriod     time.Duration
 	PongWait       time.Duration
 	WriteWait      time.Duration
+	// ShareablePolicy defines how failures during provisioning
+	// of Shareable values are handled
+	ShareablePolicy ShareablePolicy
+	// DrainGracePeriod defines how long Drain waits for
+	// in-flight calls to finish before cancelling them;
+	// DefaultDrainGracePeriod is used in case it is zero
+	DrainGracePeriod time.Duration
+	// ResponseBatcher coalesces responses into batches; optional
+	ResponseBatcher *ResponseBatcher
 }
 
 func NewWebsocketOptions() *WebsocketOptions {
@@ -44,10 +67,12 @@ func NewWebsocketOptions() *WebsocketOptions {
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
 		},
-		WriteWait:      10 * time.Second,
-		PongWait:       60 * time.Second,
-		PingPeriod:     (60 * time.Second * 9) / 10,
-		MaxMessageSize: 1 << 22,
+		WriteWait:        10 * time.Second,
+		PongWait:         60 * time.Second,
+		PingPeriod:       (60 * time.Second * 9) / 10,
+		MaxMessageSize:   1 << 22,
+		ShareablePolicy:  ShareableCacheFailures,
+		DrainGracePeriod: DefaultDrainGracePeriod,
 	}
 }
 
@@ -61,9 +86,88 @@ func NewWebsocketHandler(
 		path:          path,
 		methodHandler: methodHandler,
 		options:       options,
+		clients:       map[*WSClient]struct{}{},
 	}
 }
 
+// Drain prepares the handler for shutdown: new connections and new messages
+// on existing connections will be rejected while in-flight calls may finish
+// within the configured grace period. Calls still running after the grace period
+// will be cancelled; their contexts are finalized once their methods return.
+// Drain returns after all connections have been closed.
+func (wb *WebsocketHandler) Drain() error {
+	wb.mu.Lock()
+	wb.draining = true
+	wb.mu.Unlock()
+
+	gracePeriod := wb.options.DrainGracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultDrainGracePeriod
+	}
+	var (
+		err  error
+		done = make(chan struct{})
+	)
+	go func() {
+		wb.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(gracePeriod):
+		err = ErrDrainGracePeriodExceeded
+		wb.mu.Lock()
+		for client := range wb.clients {
+			client.cancel()
+		}
+		wb.mu.Unlock()
+		<-done
+	}
+
+	wb.mu.Lock()
+	clients := make([]*WSClient, 0, len(wb.clients))
+	for client := range wb.clients {
+		clients = append(clients, client)
+	}
+	wb.mu.Unlock()
+
+	for _, client := range clients {
+		// the writer flushes pending responses before closing the connection
+		client.stop()
+		<-client.closed
+	}
+	return err
+}
+
+// startCall registers an in-flight call;
+// false is returned in case the handler is draining
+func (wb *WebsocketHandler) startCall() bool {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	if wb.draining {
+		return false
+	}
+	wb.inflight.Add(1)
+	return true
+}
+
+func (wb *WebsocketHandler) addClient(client *WSClient) bool {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	if wb.draining {
+		return false
+	}
+	wb.clients[client] = struct{}{}
+	return true
+}
+
+func (wb *WebsocketHandler) removeClient(client *WSClient) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	delete(wb.clients, client)
+}
+
 // Handle will compare the defined path within the websocket handler
 // to the requested url path. In case paths match, a new websocket client will be registered.
 func (wb *WebsocketHandler) Handle(w http.ResponseWriter, req *http.Request) bool {
@@ -71,12 +175,25 @@ func (wb *WebsocketHandler) Handle(w http.ResponseWriter, req *http.Request) boo
 		return false
 	}
 
+	wb.mu.Lock()
+	draining := wb.draining
+	wb.mu.Unlock()
+	if draining {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	}
+
 	conn, err := wb.options.Upgrader.Upgrade(w, req, nil)
 	if err != nil {
 		log.Println(err)
 		return true
 	}
 	client := NewWSClient(wb, wb.methodHandler, conn, req)
+	if !wb.addClient(client) {
+		conn.Close()
+		return true
+	}
+	defer wb.removeClient(client)
 	client.run()
 	return true
 }
@@ -87,16 +204,51 @@ type WSClient struct {
 	conn          *websocket.Conn
 	httpRequest   *http.Request
 	send          chan []byte
+	// responses contains the responses of calls; kept apart from
+	// notifications sent using send so responses can be batched
+	responses chan []byte
+	shared    *sharedValues
+	// inflight tracks the calls of the client which may still use shared values
+	inflight sync.WaitGroup
+	// stopped is closed once the writer stopped
+	stopped chan struct{}
+	// cancel cancels all in-flight calls of the client
+	cancel context.CancelFunc
+	// quit tells the writer to flush and close the connection
+	quit     chan struct{}
+	quitOnce sync.Once
+	// closed is closed once the reader stopped
+	closed chan struct{}
 }
 
 func NewWSClient(ws *WebsocketHandler, methodHandler *MethodHandler, conn *websocket.Conn, r *http.Request) *WSClient {
-	return &WSClient{
+	ctx, cancel := context.WithCancel(r.Context())
+	client := &WSClient{
 		ws:            ws,
 		methodHandler: methodHandler,
 		conn:          conn,
-		httpRequest:   r,
+		httpRequest:   r.WithContext(ctx),
 		send:          make(chan []byte, 512),
+		responses:     make(chan []byte, 512),
+		shared:        newSharedValues(ctx, methodHandler.provider, methodHandler, ws.options.ShareablePolicy),
+		cancel:        cancel,
+		quit:          make(chan struct{}),
+		closed:        make(chan struct{}),
+		stopped:       make(chan struct{}),
 	}
+	// providers of shareable values may depend on the connection, e.g. for auth
+	client.shared.ctx.StoreValue(TypeHTTPRequest, client.httpRequest)
+	client.shared.ctx.StoreValue(TypeWSClient, client)
+	client.shared.ctx.StoreValue(TypeSecret, methodHandler.errorEncoder)
+	return client
+}
+
+// stop tells the writer to flush and close the connection;
+// stop may be called multiple times, e.g. by concurrent drains
+func (w *WSClient) stop() {
+	w.quitOnce.Do(func() {
+		close(w.quit)
+	})
 }
 
 func (w *WSClient) run() {
@@ -108,6 +260,12 @@ func (w *WSClient) run() {
 func (w *WSClient) reader() {
 	defer func() {
 		w.conn.Close()
+		w.cancel()
+		// shared values live as long as the connection
+		// and might be in use until all calls returned
+		w.inflight.Wait()
+		w.shared.finalize()
+		close(w.closed)
 	}()
 
 	w.conn.SetReadLimit(w.ws.options.MaxMessageSize)
@@ -127,7 +285,15 @@ func (w *WSClient) reader() {
 		}
 
 		if messageType == websocket.TextMessage || messageType == websocket.BinaryMessage {
+			if !w.ws.startCall() {
+				b, _ := json.Marshal(NewRPCErrorResponse(nil, ErrDraining))
+				w.enqueue(b)
+				continue
+			}
+			w.inflight.Add(1)
 			go func() {
+				defer w.ws.inflight.Done()
+				defer w.inflight.Done()
 				resp, batch := w.methodHandler.processMessages(w.httpRequest, nil, w, p)
 
 				if len(resp) == 0 {
@@ -138,13 +304,13 @@ func (w *WSClient) reader() {
 				if !batch {
 					// single response
 					b, _ := json.Marshal(resp[0])
-					w.send <- b
+					w.enqueue(b)
 					return
 				}
 
 				// batch response
 				b, _ := json.Marshal(resp)
-				w.send <- b
+				w.enqueue(b)
 			}()
 		}
 	}
@@ -155,10 +321,19 @@ func (w *WSClient) writer() {
 	defer func() {
 		ticker.Stop()
 		w.conn.Close()
+		close(w.stopped)
 	}()
 
 	for {
 		select {
+		case next := <-w.responses:
+			if w.ws.options.ResponseBatcher != nil {
+				next = w.ws.options.ResponseBatcher.collect(next, w.responses)
+			}
+			if !w.write(next) {
+				return
+			}
+
 		case next, ok := <-w.send:
 			w.conn.SetWriteDeadline(time.Now().Add(w.ws.options.WriteWait))
 			if !ok {
@@ -166,13 +341,17 @@ func (w *WSClient) writer() {
 				return
 			}
 
-			if err := w.conn.WriteMessage(websocket.TextMessage, next); err != nil {
-				if err != websocket.ErrCloseSent && !errors.Is(err, net.ErrClosed) {
-					log.Print("error: ", err)
-				}
+			if !w.write(next) {
 				return
 			}
 
+		case <-w.quit:
+			w.flush()
+			w.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "draining"),
+				time.Now().Add(w.ws.options.WriteWait))
+			return
+
 		case <-ticker.C:
 			w.conn.SetWriteDeadline(time.Now().Add(w.ws.options.WriteWait))
 			if err := w.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
@@ -185,6 +364,48 @@ func (w *WSClient) writer() {
 	}
 }
 
+// write writes a single message; false is returned in case writing failed
+func (w *WSClient) write(msg []byte) bool {
+	w.conn.SetWriteDeadline(time.Now().Add(w.ws.options.WriteWait))
+	if err := w.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+		if err != websocket.ErrCloseSent && !errors.Is(err, net.ErrClosed) {
+			log.Print("error: ", err)
+		}
+		return false
+	}
+	return true
+}
+
+// enqueue queues the response for the writer;
+// responses are dropped once the writer stopped
+func (w *WSClient) enqueue(resp []byte) {
+	select {
+	case w.responses <- resp:
+	case <-w.stopped:
+	}
+}
+
+// flush writes all pending messages
+func (w *WSClient) flush() {
+	for {
+		var next []byte
+		select {
+		case next = <-w.responses:
+		case msg, ok := <-w.send:
+			if !ok {
+				return
+			}
+			next = msg
+		default:
+			return
+		}
+		w.conn.SetWriteDeadline(time.Now().Add(w.ws.options.WriteWait))
+		if err := w.conn.WriteMessage(websocket.TextMessage, next); err != nil {
+			return
+		}
+	}
+}
+
 func (w *WSClient) SendNotification(msg *RPCNotification) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -0,0 +1,262 @@
+package jonson
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type WebsocketTest struct {
+	started   chan struct{}
+	finalized chan error
+}
+
+type websocketTestStreamV1Params struct {
+	Params
+	Millis int `json:"millis"`
+}
+
+func (w *WebsocketTest) StreamV1(ctx *Context, params *websocketTestStreamV1Params) (string, error) {
+	ctx.AfterFinalize(func(err error) {
+		w.finalized <- err
+	})
+	w.started <- struct{}{}
+	select {
+	case <-time.After(time.Duration(params.Millis) * time.Millisecond):
+		return "done", nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func TestWebsocketHandlerDrain(t *testing.T) {
+	setup := func(t *testing.T, gracePeriod time.Duration) (*WebsocketHandler, *WebsocketTest, *websocket.Conn) {
+		t.Helper()
+		system := &WebsocketTest{
+			started:   make(chan struct{}, 1),
+			finalized: make(chan error, 1),
+		}
+		mh := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+		mh.RegisterSystem(system)
+
+		options := NewWebsocketOptions()
+		options.DrainGracePeriod = gracePeriod
+		wb := NewWebsocketHandler(mh, "/ws", options)
+		server := httptest.NewServer(NewServer(wb))
+		t.Cleanup(server.Close)
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return wb, system, conn
+	}
+
+	call := func(t *testing.T, conn *websocket.Conn, millis int) {
+		t.Helper()
+		err := conn.WriteJSON(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "websocket-test/stream.v1",
+			"params":  map[string]any{"millis": millis},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("expect in-flight call to finish during drain", func(t *testing.T) {
+		wb, system, conn := setup(t, time.Second)
+		call(t, conn, 50)
+		<-system.started
+
+		responses := make(chan map[string]json.RawMessage, 1)
+		go func() {
+			resp := map[string]json.RawMessage{}
+			conn.ReadJSON(&resp)
+			responses <- resp
+		}()
+
+		if err := wb.Drain(); err != nil {
+			t.Fatalf("expected drain to succeed, got: %s", err)
+		}
+		if err := <-system.finalized; err != nil {
+			t.Fatalf("expected call to succeed, got: %s", err)
+		}
+		if resp := <-responses; string(resp["result"]) != `"done"` {
+			t.Fatalf("expected result to be done, got: %v", resp)
+		}
+	})
+
+	t.Run("expect zero grace period to fall back to the default", func(t *testing.T) {
+		wb, system, conn := setup(t, 0)
+		call(t, conn, 50)
+		<-system.started
+
+		if err := wb.Drain(); err != nil {
+			t.Fatalf("expected drain to succeed, got: %s", err)
+		}
+		if err := <-system.finalized; err != nil {
+			t.Fatalf("expected call not to be cancelled, got: %s", err)
+		}
+	})
+
+	t.Run("expect in-flight call to be cancelled after grace period", func(t *testing.T) {
+		wb, system, conn := setup(t, 50*time.Millisecond)
+		call(t, conn, 10000)
+		<-system.started
+
+		if err := wb.Drain(); !errors.Is(err, ErrDrainGracePeriodExceeded) {
+			t.Fatalf("expected ErrDrainGracePeriodExceeded, got: %v", err)
+		}
+		select {
+		case err := <-system.finalized:
+			if err == nil {
+				t.Fatal("expected cancelled call to fail")
+			}
+		default:
+			t.Fatal("expected context to be finalized")
+		}
+	})
+
+	t.Run("expect concurrent drains to close connections once", func(t *testing.T) {
+		wb, _, _ := setup(t, time.Second)
+
+		errs := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				errs <- wb.Drain()
+			}()
+		}
+		for i := 0; i < 2; i++ {
+			if err := <-errs; err != nil {
+				t.Fatalf("expected drain to succeed, got: %s", err)
+			}
+		}
+	})
+
+	t.Run("expect new connections to be rejected while draining", func(t *testing.T) {
+		wb, _, _ := setup(t, time.Second)
+		wb.Drain()
+
+		w := httptest.NewRecorder()
+		wb.Handle(w, httptest.NewRequest("GET", "/ws", nil))
+		if w.Code != 503 {
+			t.Fatalf("expected status 503, got: %d", w.Code)
+		}
+	})
+}
+
+type websocketTestSession struct {
+	Shareable
+	finalized chan struct{}
+}
+
+func (s *websocketTestSession) Finalize(errs []error) error {
+	close(s.finalized)
+	return nil
+}
+
+type WebsocketSessionTest struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (w *WebsocketSessionTest) HoldV1(ctx *Context, session *websocketTestSession) error {
+	w.started <- struct{}{}
+	<-w.release
+	return nil
+}
+
+type websocketTestAuth struct {
+	Shareable
+	user string
+}
+
+func (w *WebsocketSessionTest) WhoamiV1(ctx *Context, auth *websocketTestAuth) (string, error) {
+	return auth.user, nil
+}
+
+func TestWebsocketSharedValues(t *testing.T) {
+	session := &websocketTestSession{finalized: make(chan struct{})}
+	fac := NewFactory()
+	fac.RegisterProviderFunc(func(ctx *Context) *websocketTestSession {
+		return session
+	})
+	fac.RegisterProviderFunc(func(ctx *Context) *websocketTestAuth {
+		if RequireWSClient(ctx) == nil || RequireSecret(ctx) == nil {
+			panic(errors.New("expected connection values"))
+		}
+		return &websocketTestAuth{user: RequireHttpRequest(ctx).Header.Get("X-User")}
+	})
+	system := &WebsocketSessionTest{
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+	mh := NewMethodHandler(fac, NewDebugSecret(), nil)
+	mh.RegisterSystem(system)
+	server := httptest.NewServer(NewServer(NewWebsocketHandler(mh, "/ws", NewWebsocketOptions())))
+	defer server.Close()
+
+	t.Run("expect shared values to be finalized once in-flight calls returned", func(t *testing.T) {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = conn.WriteJSON(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "websocket-session-test/hold.v1",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-system.started
+		conn.Close()
+
+		select {
+		case <-session.finalized:
+			t.Fatal("expected shared value not to be finalized while in use")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(system.release)
+		select {
+		case <-session.finalized:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected shared value to be finalized once the call returned")
+		}
+	})
+
+	t.Run("expect providers of shared values to access the connection", func(t *testing.T) {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", http.Header{
+			"X-User": []string{"silvio"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		err = conn.WriteJSON(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "websocket-session-test/whoami.v1",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp := map[string]json.RawMessage{}
+		if err := conn.ReadJSON(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if string(resp["result"]) != `"silvio"` {
+			t.Fatalf("expected user silvio, got: %v", resp)
+		}
+	})
+}
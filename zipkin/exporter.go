@@ -0,0 +1,185 @@
+// Package zipkin exports jonson trace spans using the Zipkin v2 http api.
+// Jaeger collectors accept the same protocol when the collector's
+// zipkin endpoint is enabled (--collector.zipkin.host-port).
+//
+//	exporter := zipkin.NewExporter("http://localhost:9411/api/v2/spans", &zipkin.Options{ServiceName: "account"})
+//	defer exporter.Close()
+//	methodHandler.SetTracing(&jonson.TracingOptions{Exporter: exporter, SampleRate: 0.1})
+package zipkin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/doejon/jonson"
+)
+
+// Options configure the exporter
+type Options struct {
+	// ServiceName is reported as local endpoint of all spans
+	ServiceName string
+	// BatchSize is the maximum number of spans sent at once; defaults to 100
+	BatchSize int
+	// FlushInterval defines how often pending spans are sent; defaults to 1s
+	FlushInterval time.Duration
+	// QueueSize is the maximum number of pending spans;
+	// spans exceeding the queue will be dropped. Defaults to 1000
+	QueueSize int
+	// Client is used to send spans; defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// Exporter batches spans and sends them asynchronously
+// so exporting does not add latency to requests
+type Exporter struct {
+	endpoint string
+	options  *Options
+	queue    chan *jonson.Span
+	done     chan struct{}
+	// mu guards closed; spans exported after Close are dropped
+	mu     sync.RWMutex
+	closed bool
+}
+
+var _ jonson.SpanExporter = (*Exporter)(nil)
+
+func NewExporter(endpoint string, options *Options) *Exporter {
+	if options == nil {
+		options = &Options{}
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = 100
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = time.Second
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = 1000
+	}
+	if options.Client == nil {
+		options.Client = http.DefaultClient
+	}
+	e := &Exporter{
+		endpoint: endpoint,
+		options:  options,
+		queue:    make(chan *jonson.Span, options.QueueSize),
+		done:     make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Export queues the span; the span will be dropped in case
+// the queue is full or the exporter has been closed
+func (e *Exporter) Export(span *jonson.Span) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return
+	}
+	select {
+	case e.queue <- span:
+	default:
+		log.Print("zipkin exporter: queue full, dropping span")
+	}
+}
+
+// Close sends all pending spans and stops the exporter
+func (e *Exporter) Close() error {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.queue)
+	}
+	e.mu.Unlock()
+	<-e.done
+	return nil
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.options.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*jonson.Span, 0, e.options.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.send(batch); err != nil {
+			log.Print("zipkin exporter: ", err)
+		}
+		batch = make([]*jonson.Span, 0, e.options.BatchSize)
+	}
+
+	for {
+		select {
+		case span, ok := <-e.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= e.options.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+type zipkinSpan struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name"`
+	Kind          string            `json:"kind"`
+	Timestamp     int64             `json:"timestamp"`
+	Duration      int64             `json:"duration"`
+	LocalEndpoint *zipkinEndpoint   `json:"localEndpoint,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+func (e *Exporter) send(spans []*jonson.Span) error {
+	out := make([]*zipkinSpan, len(spans))
+	for i, span := range spans {
+		out[i] = &zipkinSpan{
+			TraceID:   span.TraceID,
+			ID:        span.SpanID,
+			ParentID:  span.ParentID,
+			Name:      span.Name,
+			Kind:      "SERVER",
+			Timestamp: span.Start.UnixMicro(),
+			Duration:  span.Duration.Microseconds(),
+			Tags:      span.Tags,
+		}
+		if e.options.ServiceName != "" {
+			out[i].LocalEndpoint = &zipkinEndpoint{ServiceName: e.options.ServiceName}
+		}
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	resp, err := e.options.Client.Post(e.endpoint, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package zipkin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/doejon/jonson"
+)
+
+type TraceTest struct{}
+
+func (t *TraceTest) OuterV1(ctx *jonson.Context) (string, error) {
+	res, err := ctx.CallMethod("trace-test/inner.v1", nil, nil)
+	if err != nil {
+		return "", err
+	}
+	return "outer:" + res.(string), nil
+}
+
+func (t *TraceTest) InnerV1(ctx *jonson.Context) (string, error) {
+	return "inner", nil
+}
+
+// collector mocks a zipkin collector
+type collector struct {
+	mu    sync.Mutex
+	spans []*zipkinSpan
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	spans := []*zipkinSpan{}
+	if err := json.NewDecoder(r.Body).Decode(&spans); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.spans = append(c.spans, spans...)
+	c.mu.Unlock()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func TestExporter(t *testing.T) {
+	call := func(t *testing.T, options *jonson.TracingOptions, headers map[string]string) {
+		t.Helper()
+		mh := jonson.NewMethodHandler(jonson.NewFactory(), jonson.NewDebugSecret(), nil)
+		mh.RegisterSystem(&TraceTest{})
+		mh.SetTracing(options)
+
+		body := []byte(`{"jsonrpc":"2.0","id":1,"method":"trace-test/outer.v1"}`)
+		req := httptest.NewRequest(http.MethodPost, "/rpc", bytes.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		jonson.NewHttpRpcHandler(mh, "/rpc").Handle(w, req)
+		if !bytes.Contains(w.Body.Bytes(), []byte(`"outer:inner"`)) {
+			t.Fatalf("expected result, got: %s", w.Body.String())
+		}
+	}
+
+	t.Run("expect nested calls to be exported as child spans", func(t *testing.T) {
+		c := &collector{}
+		server := httptest.NewServer(c)
+		defer server.Close()
+		exporter := NewExporter(server.URL, &Options{ServiceName: "trace-test"})
+
+		call(t, &jonson.TracingOptions{Exporter: exporter}, map[string]string{
+			jonson.TraceIDHeader: "463ac35c9f6413ad48485a3953bb6124",
+			jonson.SpanIDHeader:  "a2fb4a1d1a96d312",
+			jonson.SampledHeader: "1",
+		})
+		exporter.Close()
+
+		if len(c.spans) != 2 {
+			t.Fatalf("expected 2 spans, got: %d", len(c.spans))
+		}
+		inner, outer := c.spans[0], c.spans[1]
+		if outer.Name != "trace-test/outer.v1" || inner.Name != "trace-test/inner.v1" {
+			t.Fatalf("expected inner span to finish first, got: %s, %s", inner.Name, outer.Name)
+		}
+		if outer.TraceID != "463ac35c9f6413ad48485a3953bb6124" || inner.TraceID != outer.TraceID {
+			t.Fatal("expected incoming trace to be continued")
+		}
+		if outer.ParentID != "a2fb4a1d1a96d312" || inner.ParentID != outer.ID {
+			t.Fatalf("expected parent relations, got: %s, %s", outer.ParentID, inner.ParentID)
+		}
+		if outer.Tags[jonson.SpanTagMethod] != outer.Name || outer.Tags[jonson.SpanTagRequestID] == "" || outer.Tags[jonson.SpanTagResultSize] != "13" {
+			t.Fatalf("expected tags to be set, got: %v", outer.Tags)
+		}
+		if outer.LocalEndpoint == nil || outer.LocalEndpoint.ServiceName != "trace-test" {
+			t.Fatal("expected service name to be set")
+		}
+	})
+
+	t.Run("expect incoming sampling decision to be honored", func(t *testing.T) {
+		c := &collector{}
+		server := httptest.NewServer(c)
+		defer server.Close()
+		exporter := NewExporter(server.URL, nil)
+
+		call(t, &jonson.TracingOptions{Exporter: exporter, SampleRate: 1}, map[string]string{
+			jonson.TraceIDHeader: "463ac35c9f6413ad48485a3953bb6124",
+			jonson.SampledHeader: "0",
+		})
+		exporter.Close()
+
+		if len(c.spans) != 0 {
+			t.Fatalf("expected no spans, got: %d", len(c.spans))
+		}
+	})
+
+	t.Run("expect sample rate to apply to new traces", func(t *testing.T) {
+		c := &collector{}
+		server := httptest.NewServer(c)
+		defer server.Close()
+		exporter := NewExporter(server.URL, nil)
+
+		call(t, &jonson.TracingOptions{Exporter: exporter, SampleRate: 0}, nil)
+		call(t, &jonson.TracingOptions{Exporter: exporter, SampleRate: 1}, nil)
+		exporter.Close()
+
+		if len(c.spans) != 2 {
+			t.Fatalf("expected spans of a single trace, got: %d", len(c.spans))
+		}
+	})
+
+	t.Run("expect spans exported after close to be dropped", func(t *testing.T) {
+		c := &collector{}
+		server := httptest.NewServer(c)
+		defer server.Close()
+		exporter := NewExporter(server.URL, nil)
+		exporter.Close()
+
+		call(t, &jonson.TracingOptions{Exporter: exporter, SampleRate: 1}, nil)
+		exporter.Close()
+
+		if len(c.spans) != 0 {
+			t.Fatalf("expected no spans, got: %d", len(c.spans))
+		}
+	})
+}